@@ -68,6 +68,14 @@ type PodVolumeBackupStatus struct {
 
 	// Message is a message about the pod volume backup's status.
 	Message string `json:"message"`
+
+	// BytesAdded is the amount of new data, in bytes, that the snapshot
+	// added to the restic repository.
+	BytesAdded int64 `json:"bytesAdded,omitempty"`
+
+	// FilesProcessed is the number of files in the pod volume that were
+	// processed by the backup.
+	FilesProcessed int64 `json:"filesProcessed,omitempty"`
 }
 
 // +genclient
@@ -215,12 +215,12 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 	}
 
 	if groupResource == kuberesource.Pods && pod != nil {
-		// this function will return partial results, so process volumeSnapshots
+		// this function will return partial results, so process the result
 		// even if there are errors.
-		volumeSnapshots, errs := ib.backupPodVolumes(log, pod, resticVolumesToBackup)
+		result, errs := ib.backupPodVolumes(log, pod, resticVolumesToBackup)
 
 		// annotate the pod with the successful volume snapshots
-		for volume, snapshot := range volumeSnapshots {
+		for volume, snapshot := range result.Snapshots() {
 			restic.SetPodSnapshotAnnotation(metadata, volume, snapshot)
 		}
 
@@ -267,9 +267,9 @@ func (ib *defaultItemBackupper) backupItem(logger logrus.FieldLogger, obj runtim
 	return nil
 }
 
-// backupPodVolumes triggers restic backups of the specified pod volumes, and returns a map of volume name -> snapshot ID
-// for volumes that were successfully backed up, and a slice of any errors that were encountered.
-func (ib *defaultItemBackupper) backupPodVolumes(log logrus.FieldLogger, pod *corev1api.Pod, volumes []string) (map[string]string, []error) {
+// backupPodVolumes triggers restic backups of the specified pod volumes, and returns the outcome for each volume, and a
+// slice of any errors that were encountered.
+func (ib *defaultItemBackupper) backupPodVolumes(log logrus.FieldLogger, pod *corev1api.Pod, volumes []string) (*restic.PodVolumeBackupResult, []error) {
 	if len(volumes) == 0 {
 		return nil, nil
 	}
@@ -40,6 +40,7 @@ import (
 
 	v1 "github.com/heptio/velero/pkg/apis/velero/v1"
 	"github.com/heptio/velero/pkg/cloudprovider"
+	"github.com/heptio/velero/pkg/restic"
 	resticmocks "github.com/heptio/velero/pkg/restic/mocks"
 	"github.com/heptio/velero/pkg/util/collections"
 	velerotest "github.com/heptio/velero/pkg/util/test"
@@ -679,7 +680,12 @@ func TestResticAnnotationsPersist(t *testing.T) {
 
 	resticBackupper.
 		On("BackupPodVolumes", mock.Anything, mock.Anything, mock.Anything).
-		Return(map[string]string{"volume-1": "snapshot-1", "volume-2": "snapshot-2"}, nil)
+		Return(&restic.PodVolumeBackupResult{
+			Volumes: []restic.VolumeResult{
+				{Volume: "volume-1", Status: restic.VolumeResultCompleted, SnapshotID: "snapshot-1"},
+				{Volume: "volume-2", Status: restic.VolumeResultCompleted, SnapshotID: "snapshot-2"},
+			},
+		}, nil)
 
 	// our expected backed-up object is the passed-in object, plus the annotation
 	// that the backup item action adds, plus the annotations that the restic
@@ -21,10 +21,12 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubeinformers "k8s.io/client-go/informers"
 	corev1informers "k8s.io/client-go/informers/core/v1"
@@ -43,7 +45,14 @@ import (
 )
 
 func NewServerCommand(f client.Factory) *cobra.Command {
-	logLevelFlag := logging.LogLevelFlag(logrus.InfoLevel)
+	var (
+		logLevelFlag   = logging.LogLevelFlag(logrus.InfoLevel)
+		maxVolumeSize  string
+		oneFileSystem  bool
+		packSize       int
+		compression    string
+		pvcBindTimeout = restic.DefaultPVCBindTimeout
+	)
 
 	command := &cobra.Command{
 		Use:   "server",
@@ -56,7 +65,39 @@ func NewServerCommand(f client.Factory) *cobra.Command {
 			logger := logging.DefaultLogger(logLevel)
 			logger.Infof("Starting Velero restic server %s", buildinfo.FormattedGitSHA())
 
-			s, err := newResticServer(logger, fmt.Sprintf("%s-%s", c.Parent().Name(), c.Name()))
+			var maxVolumeSizeBytes int64
+			if maxVolumeSize != "" {
+				quantity, err := resource.ParseQuantity(maxVolumeSize)
+				cmd.CheckError(errors.Wrap(err, "invalid value for --max-volume-size"))
+				maxVolumeSizeBytes = quantity.Value()
+			}
+
+			var extraBackupFlags []string
+			if packSize != 0 || compression != "" {
+				if packSize != 0 {
+					cmd.CheckError(errors.Wrap(restic.ValidatePackSize(packSize), "invalid value for --pack-size"))
+				}
+				if compression != "" {
+					cmd.CheckError(errors.Wrap(restic.ValidateCompression(compression), "invalid value for --compression"))
+				}
+
+				resticVersion, err := restic.GetVersion()
+				cmd.CheckError(errors.Wrap(err, "error getting restic version"))
+
+				packSizeFlags := restic.PackSizeFlags(packSize, resticVersion)
+				if packSize != 0 && len(packSizeFlags) == 0 {
+					logger.Infof("restic %s doesn't support setting the pack size, using restic's default", resticVersion)
+				}
+
+				compressionFlags := restic.CompressionFlags(compression, resticVersion)
+				if compression != "" && len(compressionFlags) == 0 {
+					logger.Infof("restic %s doesn't support compression, backing up without it", resticVersion)
+				}
+
+				extraBackupFlags = append(packSizeFlags, compressionFlags...)
+			}
+
+			s, err := newResticServer(logger, fmt.Sprintf("%s-%s", c.Parent().Name(), c.Name()), maxVolumeSizeBytes, oneFileSystem, extraBackupFlags, pvcBindTimeout)
 			cmd.CheckError(err)
 
 			s.run()
@@ -64,6 +105,11 @@ func NewServerCommand(f client.Factory) *cobra.Command {
 	}
 
 	command.Flags().Var(logLevelFlag, "log-level", fmt.Sprintf("the level at which to log. Valid values are %s.", strings.Join(logLevelFlag.AllowedValues(), ", ")))
+	command.Flags().StringVar(&maxVolumeSize, "max-volume-size", maxVolumeSize, fmt.Sprintf("the largest volume, as a resource quantity (e.g. 100Gi), that can be backed up. Can be overridden per backup with the %s annotation. Defaults to no limit.", restic.MaxVolumeSizeAnnotation))
+	command.Flags().BoolVar(&oneFileSystem, "one-file-system", oneFileSystem, fmt.Sprintf("keep restic backups on the filesystem of the volume being backed up, without backing up other filesystems mounted inside it. Can be overridden per backup with the %s annotation.", restic.OneFileSystemAnnotation))
+	command.Flags().IntVar(&packSize, "pack-size", packSize, "the size, in MiB, of the pack files that restic backups upload. Ignored if the bundled restic doesn't support it. Defaults to restic's default.")
+	command.Flags().StringVar(&compression, "compression", compression, "the compression mode of restic backups. Valid values are auto, off and max. Requires restic repositories created with compression support. Ignored if the bundled restic doesn't support compression. Defaults to restic's default.")
+	command.Flags().DurationVar(&pvcBindTimeout, "pvc-bind-timeout", pvcBindTimeout, "how long a pod volume backup or restore waits for its volume's PVC to be bound before it fails.")
 
 	return command
 }
@@ -78,9 +124,13 @@ type resticServer struct {
 	logger                logrus.FieldLogger
 	ctx                   context.Context
 	cancelFunc            context.CancelFunc
+	maxVolumeSize         int64
+	oneFileSystem         bool
+	extraBackupFlags      []string
+	pvcBindTimeout        time.Duration
 }
 
-func newResticServer(logger logrus.FieldLogger, baseName string) (*resticServer, error) {
+func newResticServer(logger logrus.FieldLogger, baseName string, maxVolumeSize int64, oneFileSystem bool, extraBackupFlags []string, pvcBindTimeout time.Duration) (*resticServer, error) {
 	clientConfig, err := client.Config("", "", baseName)
 	if err != nil {
 		return nil, err
@@ -136,6 +186,10 @@ func newResticServer(logger logrus.FieldLogger, baseName string) (*resticServer,
 		logger:                logger,
 		ctx:                   ctx,
 		cancelFunc:            cancelFunc,
+		maxVolumeSize:         maxVolumeSize,
+		oneFileSystem:         oneFileSystem,
+		extraBackupFlags:      extraBackupFlags,
+		pvcBindTimeout:        pvcBindTimeout,
 	}, nil
 }
 
@@ -155,10 +209,16 @@ func (s *resticServer) run() {
 		s.kubeInformerFactory.Core().V1().PersistentVolumeClaims(),
 		s.veleroInformerFactory.Velero().V1().BackupStorageLocations(),
 		os.Getenv("NODE_NAME"),
+		s.maxVolumeSize,
+		s.oneFileSystem,
+		s.extraBackupFlags,
+		s.pvcBindTimeout,
 	)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		// a single worker means that this node runs at most one restic
+		// backup at a time, however many pods' PodVolumeBackups are queued.
 		backupController.Run(s.ctx, 1)
 	}()
 
@@ -171,10 +231,13 @@ func (s *resticServer) run() {
 		s.kubeInformerFactory.Core().V1().PersistentVolumeClaims(),
 		s.veleroInformerFactory.Velero().V1().BackupStorageLocations(),
 		os.Getenv("NODE_NAME"),
+		s.pvcBindTimeout,
 	)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
+		// a single worker means that this node runs at most one restic
+		// restore at a time, however many pods' PodVolumeRestores are queued.
 		restoreController.Run(s.ctx, 1)
 	}()
 
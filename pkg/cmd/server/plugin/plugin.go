@@ -19,6 +19,7 @@ package plugin
 import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/heptio/velero/pkg/backup"
 	"github.com/heptio/velero/pkg/client"
@@ -55,6 +56,21 @@ func NewCommand(f client.Factory) *cobra.Command {
 				RegisterRestoreItemAction("restic", newResticRestoreItemAction).
 				RegisterRestoreItemAction("service", newServiceRestoreItemAction).
 				RegisterRestoreItemAction("serviceaccount", newServiceAccountRestoreItemAction).
+				RegisterRestoreItemAction("change-storage-class", newChangeStorageClassRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-service-type", newChangeServiceTypeRestoreItemAction(f)).
+				RegisterRestoreItemAction("scale-down", newScaleDownRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-ingress-class", newChangeIngressClassRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-storage-size", newChangeStorageSizeRestoreItemAction(f)).
+				RegisterRestoreItemAction("add-restore-labels", newAddRestoreLabelsRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-referenced-config", newChangeReferencedConfigRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-volumesnapshotclass", newChangeVolumeSnapshotClassRestoreItemAction(f)).
+				RegisterRestoreItemAction("pause-deployments", newPauseDeploymentsRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-host-path", newChangeHostPathRestoreItemAction(f)).
+				RegisterRestoreItemAction("scale-resources", newScaleResourcesRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-priority-class", newChangePriorityClassRestoreItemAction(f)).
+				RegisterRestoreItemAction("change-annotations", newChangeAnnotationsRestoreItemAction(f)).
+				RegisterRestoreItemAction("set-selected-node", newSetSelectedNodeRestoreItemAction(f)).
+				RegisterRestoreItemAction("preserve-node-ports", newPreserveNodePortsRestoreItemAction(f)).
 				Serve()
 		},
 	}
@@ -138,3 +154,199 @@ func newServiceRestoreItemAction(logger logrus.FieldLogger) (interface{}, error)
 func newServiceAccountRestoreItemAction(logger logrus.FieldLogger) (interface{}, error) {
 	return restore.NewServiceAccountAction(logger), nil
 }
+
+func newChangeStorageClassRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeStorageClassAction(
+			logger,
+			clientset.CoreV1().ConfigMaps(f.Namespace()),
+			clientset.StorageV1().StorageClasses(),
+		), nil
+	}
+}
+
+func newChangeServiceTypeRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeServiceTypeAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newScaleDownRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewScaleDownAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newChangeIngressClassRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeIngressClassAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newChangeStorageSizeRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeStorageSizeAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newAddRestoreLabelsRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewAddRestoreLabelsAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newChangeReferencedConfigRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeReferencedConfigAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newChangeVolumeSnapshotClassRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		dynamicClient, err := f.DynamicClient()
+		if err != nil {
+			return nil, err
+		}
+
+		// volume snapshot classes are cluster-scoped.
+		volumeSnapshotClassClient, err := client.NewDynamicFactory(dynamicClient).ClientForGroupVersionResource(
+			restore.VolumeSnapshotClassGroupVersion,
+			metav1.APIResource{Name: "volumesnapshotclasses"},
+			"",
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeVolumeSnapshotClassAction(
+			logger,
+			clientset.CoreV1().ConfigMaps(f.Namespace()),
+			volumeSnapshotClassClient,
+		), nil
+	}
+}
+
+func newPauseDeploymentsRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewPauseDeploymentsAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newChangeHostPathRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeHostPathAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newScaleResourcesRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewScaleResourcesAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newChangePriorityClassRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangePriorityClassAction(
+			logger,
+			clientset.CoreV1().ConfigMaps(f.Namespace()),
+			clientset.SchedulingV1beta1().PriorityClasses(),
+		), nil
+	}
+}
+
+func newChangeAnnotationsRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewChangeAnnotationsAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
+
+func newSetSelectedNodeRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewSetSelectedNodeAction(
+			logger,
+			clientset.CoreV1().ConfigMaps(f.Namespace()),
+			clientset.CoreV1().Nodes(),
+		), nil
+	}
+}
+
+func newPreserveNodePortsRestoreItemAction(f client.Factory) veleroplugin.HandlerInitializer {
+	return func(logger logrus.FieldLogger) (interface{}, error) {
+		clientset, err := f.KubeClient()
+		if err != nil {
+			return nil, err
+		}
+
+		return restore.NewPreserveNodePortsAction(logger, clientset.CoreV1().ConfigMaps(f.Namespace())), nil
+	}
+}
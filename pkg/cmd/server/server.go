@@ -93,6 +93,9 @@ type serverConfig struct {
 	clientQPS                                        float32
 	clientBurst                                      int
 	profilerAddress                                  string
+	resticCompression                                string
+	resticCheckReadDataPercent                       int
+	resticNonBackupableVolumes                       []string
 }
 
 func NewCommand() *cobra.Command {
@@ -168,6 +171,9 @@ func NewCommand() *cobra.Command {
 	command.Flags().Float32Var(&config.clientQPS, "client-qps", config.clientQPS, "maximum number of requests per second by the server to the Kubernetes API once the burst limit has been reached")
 	command.Flags().IntVar(&config.clientBurst, "client-burst", config.clientBurst, "maximum number of requests by the server to the Kubernetes API in a short period of time")
 	command.Flags().StringVar(&config.profilerAddress, "profiler-address", config.profilerAddress, "the address to expose the pprof profiler")
+	command.Flags().StringVar(&config.resticCompression, "restic-compression", config.resticCompression, "the compression mode that new restic repositories are created to support. Valid values are auto, off and max. Ignored if the bundled restic doesn't support compression. Defaults to restic's default.")
+	command.Flags().IntVar(&config.resticCheckReadDataPercent, "restic-check-read-data-percent", config.resticCheckReadDataPercent, "the percentage of each restic repository's data to read and verify when checking the repository after it's pruned, in every maintenance run. 0 reads none and 100 reads all of it. With restic versions before 0.12.0, values in between read a random one of 100/percentage equal subsets of the data, rounded.")
+	command.Flags().StringSliceVar(&config.resticNonBackupableVolumes, "restic-non-backupable-volumes", config.resticNonBackupableVolumes, "volumes that are never backed up with restic, even if a pod's annotations list them, in addition to service account token volumes. Each entry is name=<volume name> or type=<volume type>, where the type is as in a pod spec, e.g. type=emptyDir.")
 
 	return command
 }
@@ -478,14 +484,43 @@ func (s *server) initRestic() error {
 	)
 	go secretsInformer.Run(s.ctx.Done())
 
+	var initRepoFlags []string
+	if s.config.resticCompression != "" {
+		if err := restic.ValidateCompression(s.config.resticCompression); err != nil {
+			return errors.Wrap(err, "invalid value for --restic-compression")
+		}
+
+		resticVersion, err := restic.GetVersion()
+		if err != nil {
+			return errors.Wrap(err, "error getting restic version")
+		}
+
+		if initRepoFlags = restic.InitRepositoryFlags(s.config.resticCompression, resticVersion); len(initRepoFlags) == 0 && s.config.resticCompression != "off" {
+			s.logger.Infof("restic %s doesn't support compression, creating restic repositories without it", resticVersion)
+		}
+	}
+
+	if err := restic.ValidateReadDataPercent(s.config.resticCheckReadDataPercent); err != nil {
+		return errors.Wrap(err, "invalid value for --restic-check-read-data-percent")
+	}
+
+	if err := restic.ValidateNonBackupableVolumes(s.config.resticNonBackupableVolumes); err != nil {
+		return errors.Wrap(err, "invalid value for --restic-non-backupable-volumes")
+	}
+
 	res, err := restic.NewRepositoryManager(
 		s.ctx,
 		s.namespace,
 		s.veleroClient,
+		s.kubeClient.CoreV1(),
+		s.kubeClient.CoreV1(),
+		restic.NewEventRecorder(s.kubeClient.CoreV1(), "velero", s.logger),
 		secretsInformer,
 		s.sharedInformerFactory.Velero().V1().ResticRepositories(),
 		s.veleroClient.VeleroV1(),
 		s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
+		initRepoFlags,
+		s.config.resticNonBackupableVolumes,
 		s.logger,
 	)
 	if err != nil {
@@ -672,6 +707,7 @@ func (s *server) runControllers(defaultVolumeSnapshotLocations map[string]string
 		s.veleroClient.VeleroV1(),
 		s.sharedInformerFactory.Velero().V1().BackupStorageLocations(),
 		s.resticManager,
+		s.config.resticCheckReadDataPercent,
 	)
 	wg.Add(1)
 	go func() {
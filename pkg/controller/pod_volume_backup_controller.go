@@ -21,12 +21,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
@@ -52,6 +56,10 @@ type podVolumeBackupController struct {
 	pvcLister             corev1listers.PersistentVolumeClaimLister
 	backupLocationLister  listers.BackupStorageLocationLister
 	nodeName              string
+	maxVolumeSize         int64
+	oneFileSystem         bool
+	extraBackupFlags      []string
+	pvcBindTimeout        time.Duration
 
 	processBackupFunc func(*velerov1api.PodVolumeBackup) error
 	fileSystem        filesystem.Interface
@@ -67,6 +75,10 @@ func NewPodVolumeBackupController(
 	pvcInformer corev1informers.PersistentVolumeClaimInformer,
 	backupLocationInformer informers.BackupStorageLocationInformer,
 	nodeName string,
+	maxVolumeSize int64,
+	oneFileSystem bool,
+	extraBackupFlags []string,
+	pvcBindTimeout time.Duration,
 ) Interface {
 	c := &podVolumeBackupController{
 		genericController:     newGenericController("pod-volume-backup", logger),
@@ -77,6 +89,10 @@ func NewPodVolumeBackupController(
 		pvcLister:             pvcInformer.Lister(),
 		backupLocationLister:  backupLocationInformer.Lister(),
 		nodeName:              nodeName,
+		maxVolumeSize:         maxVolumeSize,
+		oneFileSystem:         oneFileSystem,
+		extraBackupFlags:      extraBackupFlags,
+		pvcBindTimeout:        pvcBindTimeout,
 
 		fileSystem: filesystem.NewFileSystem(),
 	}
@@ -147,11 +163,48 @@ func (c *podVolumeBackupController) processQueueItem(key string) error {
 		return nil
 	}
 
+	// check again later, rather than blocking the worker, if the volume's
+	// PVC isn't bound yet.
+	if isWaitingForPVCBind(c.podLister, c.pvcLister, req.Spec.Pod, req.Spec.Volume, req.CreationTimestamp, c.pvcBindTimeout) {
+		log.Debugf("Volume's PVC isn't bound yet, requeueing after %v", pvcBindRequeueInterval)
+		c.queue.AddAfter(key, pvcBindRequeueInterval)
+		return nil
+	}
+
 	// Don't mutate the shared cache
 	reqCopy := req.DeepCopy()
 	return c.processBackupFunc(reqCopy)
 }
 
+// pvcBindRequeueInterval is how long the pod volume backup and restore
+// controllers wait before processing an item again when its volume's PVC
+// isn't bound yet.
+var pvcBindRequeueInterval = 5 * time.Second
+
+// isWaitingForPVCBind returns true if the pod volume is a PVC that isn't bound
+// yet, and bindTimeout hasn't passed since created. Errors getting the pod or
+// the PVC return false, so they're reported when the item is processed.
+func isWaitingForPVCBind(
+	podLister corev1listers.PodLister,
+	pvcLister corev1listers.PersistentVolumeClaimLister,
+	podRef corev1api.ObjectReference,
+	volume string,
+	created metav1.Time,
+	bindTimeout time.Duration,
+) bool {
+	if time.Since(created.Time) >= bindTimeout {
+		return false
+	}
+
+	pod, err := podLister.Pods(podRef.Namespace).Get(podRef.Name)
+	if err != nil {
+		return false
+	}
+
+	_, err = kube.GetVolumeDirectory(pod, volume, pvcLister)
+	return kube.IsPVCNotBound(err)
+}
+
 func loggerForPodVolumeBackup(baseLogger logrus.FieldLogger, req *velerov1api.PodVolumeBackup) logrus.FieldLogger {
 	log := baseLogger.WithFields(logrus.Fields{
 		"namespace": req.Namespace,
@@ -165,20 +218,38 @@ func loggerForPodVolumeBackup(baseLogger logrus.FieldLogger, req *velerov1api.Po
 	return log
 }
 
-func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBackup) error {
+func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBackup) (err error) {
 	log := loggerForPodVolumeBackup(c.logger, req)
 
-	log.Info("Backup starting")
-
-	var err error
-
-	// update status to InProgress
-	req, err = c.patchPodVolumeBackup(req, updatePhaseFunc(velerov1api.PodVolumeBackupPhaseInProgress))
+	// update status to InProgress. This is an update rather than a patch,
+	// so that it fails if the PodVolumeBackup was cancelled by the backup
+	// it's part of since it was retrieved.
+	inProgress := req.DeepCopy()
+	inProgress.Status.Phase = velerov1api.PodVolumeBackupPhaseInProgress
+	req, err = c.podVolumeBackupClient.PodVolumeBackups(inProgress.Namespace).Update(inProgress)
+	if apierrors.IsConflict(err) {
+		// requeue, so the PodVolumeBackup is only processed if its phase
+		// is still New.
+		log.Debug("PodVolumeBackup changed since it was retrieved, requeueing")
+		return errors.WithStack(err)
+	}
 	if err != nil {
 		log.WithError(err).Error("Error setting phase to InProgress")
 		return errors.WithStack(err)
 	}
 
+	log.Info("Backup starting")
+
+	// if anything below panics, fail the PodVolumeBackup rather than leaving
+	// it InProgress, so the backup waiting for it doesn't hang until it times
+	// out.
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("stack", string(debug.Stack())).Errorf("Panic processing PodVolumeBackup: %v", r)
+			err = c.fail(req, fmt.Sprintf("panic processing PodVolumeBackup: %v", r), log)
+		}
+	}()
+
 	pod, err := c.podLister.Pods(req.Spec.Pod.Namespace).Get(req.Spec.Pod.Name)
 	if err != nil {
 		log.WithError(err).Errorf("Error getting pod %s/%s", req.Spec.Pod.Namespace, req.Spec.Pod.Name)
@@ -201,6 +272,24 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 	}
 	log.WithField("path", path).Debugf("Found path matching glob")
 
+	oneFileSystem, err := restic.GetOneFileSystem(req, c.oneFileSystem)
+	if err != nil {
+		log.WithError(err).Error("Error getting one-file-system setting")
+		return c.fail(req, errors.Wrap(err, "error getting one-file-system setting").Error(), log)
+	}
+
+	maxVolumeSize, err := restic.GetMaxVolumeSize(req, c.maxVolumeSize)
+	if err != nil {
+		log.WithError(err).Error("Error getting max volume size")
+		return c.fail(req, errors.Wrap(err, "error getting max volume size").Error(), log)
+	}
+	if maxVolumeSize > 0 {
+		if err := restic.EnsureVolumeSizeWithinLimit(c.fileSystem, path, maxVolumeSize, oneFileSystem); err != nil {
+			log.WithError(err).Error("Error checking volume size")
+			return c.fail(req, err.Error(), log)
+		}
+	}
+
 	// temp creds
 	file, err := restic.TempCredentialsFile(c.secretLister, req.Namespace, req.Spec.Pod.Namespace, c.fileSystem)
 	if err != nil {
@@ -216,6 +305,8 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 		path,
 		req.Spec.Tags,
 	)
+	resticCmd.ExtraFlags = append(resticCmd.ExtraFlags, c.extraBackupFlags...)
+	resticCmd.ExtraFlags = append(resticCmd.ExtraFlags, restic.OneFileSystemFlags(oneFileSystem)...)
 
 	// if this is azure, set resticCmd.Env appropriately
 	var env []string
@@ -228,6 +319,7 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 
 	var stdout, stderr string
 
+	log.Debugf("Running command=%s", resticCmd.String())
 	if stdout, stderr, err = veleroexec.RunCommand(resticCmd.Cmd()); err != nil {
 		log.WithError(errors.WithStack(err)).Errorf("Error running command=%s, stdout=%s, stderr=%s", resticCmd.String(), stdout, stderr)
 		return c.fail(req, fmt.Sprintf("error running restic backup, stderr=%s: %s", stderr, err.Error()), log)
@@ -240,10 +332,19 @@ func (c *podVolumeBackupController) processBackup(req *velerov1api.PodVolumeBack
 		return c.fail(req, errors.Wrap(err, "error getting snapshot id").Error(), log)
 	}
 
-	// update status to Completed with path & snapshot id
+	// the summary is informational, so don't fail the backup if it can't be parsed.
+	summary, err := restic.GetBackupSummary(stdout)
+	if err != nil {
+		log.WithError(err).Warn("Error getting restic backup summary")
+		summary = new(restic.BackupSummary)
+	}
+
+	// update status to Completed with path, snapshot id & summary
 	req, err = c.patchPodVolumeBackup(req, func(r *velerov1api.PodVolumeBackup) {
 		r.Status.Path = path
 		r.Status.SnapshotID = snapshotID
+		r.Status.BytesAdded = summary.BytesAdded
+		r.Status.FilesProcessed = summary.FilesProcessed
 		r.Status.Phase = velerov1api.PodVolumeBackupPhaseCompleted
 	})
 	if err != nil {
@@ -296,12 +397,6 @@ func (c *podVolumeBackupController) fail(req *velerov1api.PodVolumeBackup, msg s
 	return nil
 }
 
-func updatePhaseFunc(phase velerov1api.PodVolumeBackupPhase) func(r *velerov1api.PodVolumeBackup) {
-	return func(r *velerov1api.PodVolumeBackup) {
-		r.Status.Phase = phase
-	}
-}
-
 func singlePathMatch(path string) (string, error) {
 	matches, err := filepath.Glob(path)
 	if err != nil {
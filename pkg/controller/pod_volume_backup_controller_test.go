@@ -18,11 +18,23 @@ package controller
 
 import (
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	core "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerofake "github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	listers "github.com/heptio/velero/pkg/generated/listers/velero/v1"
 	velerotest "github.com/heptio/velero/pkg/util/test"
 )
 
@@ -169,3 +181,183 @@ func TestPVBHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessQueueItemWaitsForPVCBind(t *testing.T) {
+	pvcBindRequeueInterval = 10 * time.Millisecond
+	defer func() { pvcBindRequeueInterval = 5 * time.Second }()
+
+	pod := &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "pod-1"},
+		Spec: corev1api.PodSpec{
+			Volumes: []corev1api.Volume{
+				{
+					Name: "vol-1",
+					VolumeSource: corev1api.VolumeSource{
+						PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+					},
+				},
+			},
+		},
+	}
+
+	unboundPVC := &corev1api.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "pvc-1"},
+		Status:     corev1api.PersistentVolumeClaimStatus{Phase: corev1api.ClaimPending},
+	}
+	boundPVC := unboundPVC.DeepCopy()
+	boundPVC.Spec.VolumeName = "pv-1"
+	boundPVC.Status.Phase = corev1api.ClaimBound
+
+	tests := []struct {
+		name            string
+		pvc             *corev1api.PersistentVolumeClaim
+		age             time.Duration
+		expectProcessed bool
+	}{
+		{
+			name:            "bound PVC is processed",
+			pvc:             boundPVC,
+			expectProcessed: true,
+		},
+		{
+			name:            "unbound PVC is requeued before the timeout",
+			pvc:             unboundPVC,
+			age:             time.Minute,
+			expectProcessed: false,
+		},
+		{
+			name:            "unbound PVC is processed after the timeout",
+			pvc:             unboundPVC,
+			age:             10 * time.Minute,
+			expectProcessed: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pvb := &velerov1api.PodVolumeBackup{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:         "velero",
+					Name:              "pvb-1",
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-test.age)),
+				},
+				Spec: velerov1api.PodVolumeBackupSpec{
+					Pod: corev1api.ObjectReference{
+						Namespace: "ns-1",
+						Name:      "pod-1",
+					},
+					Volume: "vol-1",
+				},
+			}
+
+			pvbIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.NoError(t, pvbIndexer.Add(pvb))
+			podIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.NoError(t, podIndexer.Add(pod))
+			pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			require.NoError(t, pvcIndexer.Add(test.pvc))
+
+			var processed bool
+			c := &podVolumeBackupController{
+				genericController:     newGenericController("pod-volume-backup", velerotest.NewLogger()),
+				podVolumeBackupLister: listers.NewPodVolumeBackupLister(pvbIndexer),
+				podLister:             corev1listers.NewPodLister(podIndexer),
+				pvcLister:             corev1listers.NewPersistentVolumeClaimLister(pvcIndexer),
+				pvcBindTimeout:        5 * time.Minute,
+				processBackupFunc: func(*velerov1api.PodVolumeBackup) error {
+					processed = true
+					return nil
+				},
+			}
+
+			require.NoError(t, c.processQueueItem("velero/pvb-1"))
+			assert.Equal(t, test.expectProcessed, processed)
+
+			if !test.expectProcessed {
+				// the PodVolumeBackup is added back to the queue after the
+				// requeue interval.
+				assert.NoError(t, wait.Poll(10*time.Millisecond, time.Second, func() (bool, error) {
+					return c.queue.Len() == 1, nil
+				}))
+			}
+		})
+	}
+}
+
+func TestProcessBackupFailsPodVolumeBackupOnPanic(t *testing.T) {
+	pvb := &velerov1api.PodVolumeBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      "pvb-1",
+		},
+		Spec: velerov1api.PodVolumeBackupSpec{
+			Pod: corev1api.ObjectReference{
+				Namespace: "ns-1",
+				Name:      "pod-1",
+			},
+			Volume: "vol-1",
+		},
+	}
+	client := velerofake.NewSimpleClientset(pvb)
+
+	c := &podVolumeBackupController{
+		genericController:     newGenericController("pod-volume-backup", velerotest.NewLogger()),
+		podVolumeBackupClient: client.VeleroV1(),
+		podLister:             &panickingPodLister{},
+	}
+
+	require.NoError(t, c.processBackup(pvb.DeepCopy()))
+
+	res, err := client.VeleroV1().PodVolumeBackups("velero").Get("pvb-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, velerov1api.PodVolumeBackupPhaseFailed, res.Status.Phase)
+	assert.Equal(t, "panic processing PodVolumeBackup: unexpected pod", res.Status.Message)
+}
+
+func TestProcessBackupDoesNotStartChangedPodVolumeBackup(t *testing.T) {
+	pvb := &velerov1api.PodVolumeBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      "pvb-1",
+		},
+		Spec: velerov1api.PodVolumeBackupSpec{
+			Pod: corev1api.ObjectReference{
+				Namespace: "ns-1",
+				Name:      "pod-1",
+			},
+			Volume: "vol-1",
+		},
+	}
+	client := velerofake.NewSimpleClientset(pvb)
+
+	// the update fails the way it would if the backup had cancelled the
+	// PodVolumeBackup since it was retrieved.
+	client.PrependReactor("update", "podvolumebackups", func(action core.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(velerov1api.Resource("podvolumebackups"), "pvb-1", errors.New("conflict"))
+	})
+
+	// the pod lister panics if it's used, which fails the PodVolumeBackup,
+	// so the backup must not get that far.
+	c := &podVolumeBackupController{
+		genericController:     newGenericController("pod-volume-backup", velerotest.NewLogger()),
+		podVolumeBackupClient: client.VeleroV1(),
+		podLister:             &panickingPodLister{},
+	}
+
+	err := c.processBackup(pvb.DeepCopy())
+	require.Error(t, err)
+	assert.True(t, apierrors.IsConflict(errors.Cause(err)))
+
+	res, err := client.VeleroV1().PodVolumeBackups("velero").Get("pvb-1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, res.Status.Phase)
+}
+
+// panickingPodLister is a PodLister that panics when a pod is retrieved.
+type panickingPodLister struct {
+	corev1listers.PodLister
+}
+
+func (l *panickingPodLister) Pods(namespace string) corev1listers.PodNamespaceLister {
+	panic("unexpected pod")
+}
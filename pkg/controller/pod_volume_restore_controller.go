@@ -22,7 +22,9 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
+	"time"
 
 	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/pkg/errors"
@@ -56,6 +58,7 @@ type podVolumeRestoreController struct {
 	pvcLister              corev1listers.PersistentVolumeClaimLister
 	backupLocationLister   listers.BackupStorageLocationLister
 	nodeName               string
+	pvcBindTimeout         time.Duration
 
 	processRestoreFunc func(*velerov1api.PodVolumeRestore) error
 	fileSystem         filesystem.Interface
@@ -71,6 +74,7 @@ func NewPodVolumeRestoreController(
 	pvcInformer corev1informers.PersistentVolumeClaimInformer,
 	backupLocationInformer informers.BackupStorageLocationInformer,
 	nodeName string,
+	pvcBindTimeout time.Duration,
 ) Interface {
 	c := &podVolumeRestoreController{
 		genericController:      newGenericController("pod-volume-restore", logger),
@@ -81,6 +85,7 @@ func NewPodVolumeRestoreController(
 		pvcLister:              pvcInformer.Lister(),
 		backupLocationLister:   backupLocationInformer.Lister(),
 		nodeName:               nodeName,
+		pvcBindTimeout:         pvcBindTimeout,
 
 		fileSystem: filesystem.NewFileSystem(),
 	}
@@ -232,6 +237,14 @@ func (c *podVolumeRestoreController) processQueueItem(key string) error {
 		return errors.Wrap(err, "error getting PodVolumeRestore")
 	}
 
+	// check again later, rather than blocking the worker, if the volume's
+	// PVC isn't bound yet.
+	if isWaitingForPVCBind(c.podLister, c.pvcLister, req.Spec.Pod, req.Spec.Volume, req.CreationTimestamp, c.pvcBindTimeout) {
+		log.Debugf("Volume's PVC isn't bound yet, requeueing after %v", pvcBindRequeueInterval)
+		c.queue.AddAfter(key, pvcBindRequeueInterval)
+		return nil
+	}
+
 	// Don't mutate the shared cache
 	reqCopy := req.DeepCopy()
 	return c.processRestoreFunc(reqCopy)
@@ -250,13 +263,11 @@ func loggerForPodVolumeRestore(baseLogger logrus.FieldLogger, req *velerov1api.P
 	return log
 }
 
-func (c *podVolumeRestoreController) processRestore(req *velerov1api.PodVolumeRestore) error {
+func (c *podVolumeRestoreController) processRestore(req *velerov1api.PodVolumeRestore) (err error) {
 	log := loggerForPodVolumeRestore(c.logger, req)
 
 	log.Info("Restore starting")
 
-	var err error
-
 	// update status to InProgress
 	req, err = c.patchPodVolumeRestore(req, updatePodVolumeRestorePhaseFunc(velerov1api.PodVolumeRestorePhaseInProgress))
 	if err != nil {
@@ -264,6 +275,16 @@ func (c *podVolumeRestoreController) processRestore(req *velerov1api.PodVolumeRe
 		return errors.WithStack(err)
 	}
 
+	// if anything below panics, fail the PodVolumeRestore rather than leaving
+	// it InProgress, so the restore waiting for it doesn't hang until it times
+	// out.
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithField("stack", string(debug.Stack())).Errorf("Panic processing PodVolumeRestore: %v", r)
+			err = c.failRestore(req, fmt.Sprintf("panic processing PodVolumeRestore: %v", r), log)
+		}
+	}()
+
 	pod, err := c.podLister.Pods(req.Spec.Pod.Namespace).Get(req.Spec.Pod.Name)
 	if err != nil {
 		log.WithError(err).Errorf("Error getting pod %s/%s", req.Spec.Pod.Namespace, req.Spec.Pod.Name)
@@ -327,6 +348,7 @@ func (c *podVolumeRestoreController) restorePodVolume(req *velerov1api.PodVolume
 
 	var stdout, stderr string
 
+	log.Debugf("Running command=%s", resticCmd.String())
 	if stdout, stderr, err = veleroexec.RunCommand(resticCmd.Cmd()); err != nil {
 		return errors.Wrapf(err, "error running restic restore, cmd=%s, stdout=%s, stderr=%s", resticCmd.String(), stdout, stderr)
 	}
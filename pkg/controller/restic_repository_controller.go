@@ -44,6 +44,7 @@ type resticRepositoryController struct {
 	resticRepositoryLister listers.ResticRepositoryLister
 	backupLocationLister   listers.BackupStorageLocationLister
 	repositoryManager      restic.RepositoryManager
+	checkReadDataPercent   int
 
 	clock clock.Clock
 }
@@ -55,6 +56,7 @@ func NewResticRepositoryController(
 	resticRepositoryClient velerov1client.ResticRepositoriesGetter,
 	backupLocationInformer informers.BackupStorageLocationInformer,
 	repositoryManager restic.RepositoryManager,
+	checkReadDataPercent int,
 ) Interface {
 	c := &resticRepositoryController{
 		genericController:      newGenericController("restic-repository", logger),
@@ -62,6 +64,7 @@ func NewResticRepositoryController(
 		resticRepositoryLister: resticRepositoryInformer.Lister(),
 		backupLocationLister:   backupLocationInformer.Lister(),
 		repositoryManager:      repositoryManager,
+		checkReadDataPercent:   checkReadDataPercent,
 		clock:                  &clock.RealClock{},
 	}
 
@@ -163,12 +166,19 @@ func (c *resticRepositoryController) initializeRepo(req *v1.ResticRepository, lo
 }
 
 // ensureRepo first checks the repo, and returns if check passes. If it fails,
-// attempts to init the repo, and returns the result.
+// and the repo isn't usable for a known reason other than not being
+// initialized, returns that reason. Otherwise, attempts to init the repo, and
+// returns the result.
 func ensureRepo(repo *v1.ResticRepository, repoManager restic.RepositoryManager) error {
-	if repoManager.CheckRepo(repo) == nil {
+	if repoManager.CheckRepo(repo, 0) == nil {
 		return nil
 	}
 
+	status, err := repoManager.RepositoryStatus(repo)
+	if err == nil && status.Health != restic.RepoHealthNotInitialized && status.Health != restic.RepoHealthHealthy {
+		return errors.Errorf("restic repository is not usable (%s): %s", status.Health, status.Message)
+	}
+
 	return repoManager.InitRepo(repo)
 }
 
@@ -185,7 +195,7 @@ func (c *resticRepositoryController) runMaintenanceIfDue(req *v1.ResticRepositor
 	log.Info("Running maintenance on restic repository")
 
 	log.Debug("Checking repo before prune")
-	if err := c.repositoryManager.CheckRepo(req); err != nil {
+	if err := c.repositoryManager.CheckRepo(req, 0); err != nil {
 		return c.patchResticRepository(req, repoNotReady(err.Error()))
 	}
 
@@ -201,8 +211,10 @@ func (c *resticRepositoryController) runMaintenanceIfDue(req *v1.ResticRepositor
 		}
 	}
 
+	// only the check after pruning reads data, since reading data can
+	// take a long time.
 	log.Debug("Checking repo after prune")
-	if err := c.repositoryManager.CheckRepo(req); err != nil {
+	if err := c.repositoryManager.CheckRepo(req, c.checkReadDataPercent); err != nil {
 		return c.patchResticRepository(req, repoNotReady(err.Error()))
 	}
 
@@ -0,0 +1,77 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+
+	"github.com/heptio/velero/pkg/util/kube"
+)
+
+// VolumeBackupPlan describes what a backup would do with one of the volumes
+// listed in a pod's backup-volumes annotation.
+type VolumeBackupPlan struct {
+	// VolumeName is the name of the volume in the pod.
+	VolumeName string
+
+	// VolumeDir is the name of the volume's directory on the pod's node,
+	// under /var/lib/kubelet/pods/<pod UID>/volumes/<volume plugin>/. It's
+	// empty if the volume is skipped.
+	VolumeDir string
+
+	// SkippedReason is why the volume wouldn't be backed up with restic, or
+	// empty if it would be.
+	SkippedReason string
+}
+
+// PlanPodVolumeBackup returns what a backup would do with each of the volumes
+// listed in the pod's backup-volumes annotation, in the order they're listed,
+// without creating any PodVolumeBackups or running any restic commands.
+// Volumes are skipped for the same reasons as in BackupPodVolumes, including
+// restic backup being disabled for the pod's namespace. An error
+// is returned if the directory of a volume that would be backed up can't be
+// resolved, since its backup would fail.
+func (b *backupper) PlanPodVolumeBackup(pod *corev1api.Pod, pvcLister corev1listers.PersistentVolumeClaimLister, log logrus.FieldLogger) ([]VolumeBackupPlan, error) {
+	podVolumes := make(map[string]corev1api.Volume)
+	for _, podVolume := range pod.Spec.Volumes {
+		podVolumes[podVolume.Name] = podVolume
+	}
+
+	var plans []VolumeBackupPlan
+	for _, volumeName := range GetVolumesToBackup(pod) {
+		plan := VolumeBackupPlan{VolumeName: volumeName}
+
+		if reason, _ := b.volumeSkipReason(pod, podVolumes, volumeName, log); reason != "" {
+			plan.SkippedReason = reason
+			plans = append(plans, plan)
+			continue
+		}
+
+		volumeDir, err := kube.GetVolumeDirectory(pod, volumeName, pvcLister)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting directory of volume %s in pod %s/%s", volumeName, pod.Namespace, pod.Name)
+		}
+		plan.VolumeDir = volumeDir
+
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
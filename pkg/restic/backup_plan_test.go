@@ -0,0 +1,155 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestPlanPodVolumeBackup(t *testing.T) {
+	pod := &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns-1",
+			Name:      "pod-1",
+		},
+		Spec: corev1api.PodSpec{
+			Volumes: []corev1api.Volume{
+				{Name: "empty-dir"},
+				{
+					Name: "pvc",
+					VolumeSource: corev1api.VolumeSource{
+						PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+					},
+				},
+				{
+					Name: "host-path",
+					VolumeSource: corev1api.VolumeSource{
+						HostPath: &corev1api.HostPathVolumeSource{Path: "/tmp"},
+					},
+				},
+				{
+					Name: "token",
+					VolumeSource: corev1api.VolumeSource{
+						Projected: &corev1api.ProjectedVolumeSource{
+							Sources: []corev1api.VolumeProjection{
+								{ServiceAccountToken: &corev1api.ServiceAccountTokenProjection{Path: "token"}},
+							},
+						},
+					},
+				},
+				{
+					Name: "unbound-pvc",
+					VolumeSource: corev1api.VolumeSource{
+						PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-2"},
+					},
+				},
+			},
+		},
+	}
+
+	pvcIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, pvcIndexer.Add(&corev1api.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "pvc-1"},
+		Spec:       corev1api.PersistentVolumeClaimSpec{VolumeName: "pv-1"},
+		Status:     corev1api.PersistentVolumeClaimStatus{Phase: corev1api.ClaimBound},
+	}))
+	pvcLister := corev1listers.NewPersistentVolumeClaimLister(pvcIndexer)
+
+	b := &backupper{
+		repoManager: &repositoryManager{
+			namespaceClient: &fakeNamespaceClient{
+				namespaces: []corev1api.Namespace{
+					{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:        "ns-2",
+							Annotations: map[string]string{BackupNamespaceAnnotation: "disabled"},
+						},
+					},
+				},
+			},
+		},
+		disabledNamespaces:         make(map[string]bool),
+		nonBackupableVolumeFilters: defaultNonBackupableVolumeFilters,
+	}
+
+	tests := []struct {
+		name          string
+		namespace     string
+		volumes       string
+		expectedPlans []VolumeBackupPlan
+		expectedErr   bool
+	}{
+		{
+			name: "pod with no volumes to back up returns no plans",
+		},
+		{
+			name:    "volumes are returned in annotation order with their directories or skipped reasons",
+			volumes: "pvc,empty-dir,missing,host-path,token",
+			expectedPlans: []VolumeBackupPlan{
+				{VolumeName: "pvc", VolumeDir: "pv-1"},
+				{VolumeName: "empty-dir", VolumeDir: "empty-dir"},
+				{VolumeName: "missing", SkippedReason: "volume not found in pod"},
+				{VolumeName: "host-path", SkippedReason: "hostPath volumes are not supported for restic backup"},
+				{VolumeName: "token", SkippedReason: "volume contains ephemeral data that can't be usefully backed up with restic"},
+			},
+		},
+		{
+			name:      "volumes of a pod in a namespace with restic backup disabled are skipped",
+			namespace: "ns-2",
+			volumes:   "empty-dir,host-path",
+			expectedPlans: []VolumeBackupPlan{
+				{VolumeName: "empty-dir", SkippedReason: "restic backup is disabled for the pod's namespace"},
+				{VolumeName: "host-path", SkippedReason: "restic backup is disabled for the pod's namespace"},
+			},
+		},
+		{
+			name:        "volume whose PVC can't be found returns an error",
+			volumes:     "empty-dir,unbound-pvc",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := pod.DeepCopy()
+			if test.namespace != "" {
+				pod.Namespace = test.namespace
+			}
+			if test.volumes != "" {
+				pod.Annotations = map[string]string{volumesToBackupAnnotation: test.volumes}
+			}
+
+			plans, err := b.PlanPodVolumeBackup(pod, pvcLister, velerotest.NewLogger())
+
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedPlans, plans)
+		})
+	}
+}
@@ -19,12 +19,18 @@ package restic
 import (
 	"context"
 	"fmt"
+	"reflect"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
 	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
@@ -33,8 +39,13 @@ import (
 
 // Backupper can execute restic backups of volumes in a pod.
 type Backupper interface {
-	// BackupPodVolumes backs up all annotated volumes in a pod.
-	BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.Pod, log logrus.FieldLogger) (map[string]string, []error)
+	// BackupPodVolumes backs up all annotated volumes in a pod, and returns
+	// the outcome for each of them along with any errors.
+	BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.Pod, log logrus.FieldLogger) (*PodVolumeBackupResult, []error)
+
+	// PlanPodVolumeBackup returns what BackupPodVolumes would do with each
+	// annotated volume in a pod, without backing any of them up.
+	PlanPodVolumeBackup(pod *corev1api.Pod, pvcLister corev1listers.PersistentVolumeClaimLister, log logrus.FieldLogger) ([]VolumeBackupPlan, error)
 }
 
 type backupper struct {
@@ -44,8 +55,46 @@ type backupper struct {
 
 	results     map[string]chan *velerov1api.PodVolumeBackup
 	resultsLock sync.Mutex
+
+	// pvcSnapshots holds the IDs of the restic snapshots taken so far in
+	// this backup of PVC volumes, keyed by <namespace>/<claim name>, so that
+	// a PVC mounted by multiple pods is only backed up once.
+	pvcSnapshots     map[string]string
+	pvcSnapshotsLock sync.Mutex
+
+	// disabledNamespaces caches whether restic backup is disabled for
+	// each namespace that's been checked in this backup.
+	disabledNamespaces     map[string]bool
+	disabledNamespacesLock sync.Mutex
+
+	// nonBackupableVolumeFilters identify volumes that are never backed up
+	// with restic, even if they're listed in the pod's backup-volumes
+	// annotation, because their contents are ephemeral.
+	nonBackupableVolumeFilters []volumeFilter
+}
+
+// volumeFilter returns true if the specified volume of the pod matches
+// the filter.
+type volumeFilter func(pod *corev1api.Pod, volume corev1api.Volume) bool
+
+// defaultNonBackupableVolumeFilters are the filters that identify volumes that
+// are skipped for restic backup by default.
+var defaultNonBackupableVolumeFilters = []volumeFilter{
+	isServiceAccountTokenVolume,
 }
 
+// nonBackupableVolumeNamePrefix and nonBackupableVolumeTypePrefix prefix
+// the entries passed to NewRepositoryManager that identify non-backupable
+// volumes by name and by type.
+const (
+	nonBackupableVolumeNamePrefix = "name="
+	nonBackupableVolumeTypePrefix = "type="
+)
+
+// serviceAccountTokenMountPath is where the default service account token
+// secret is mounted into a pod's containers.
+const serviceAccountTokenMountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
 func newBackupper(
 	ctx context.Context,
 	repoManager *repositoryManager,
@@ -58,7 +107,11 @@ func newBackupper(
 		repoManager: repoManager,
 		repoEnsurer: repoEnsurer,
 
-		results: make(map[string]chan *velerov1api.PodVolumeBackup),
+		results:            make(map[string]chan *velerov1api.PodVolumeBackup),
+		pvcSnapshots:       make(map[string]string),
+		disabledNamespaces: make(map[string]bool),
+
+		nonBackupableVolumeFilters: append(append([]volumeFilter{}, defaultNonBackupableVolumeFilters...), repoManager.nonBackupableVolumeFilters...),
 	}
 
 	podVolumeBackupInformer.AddEventHandler(
@@ -88,16 +141,40 @@ func resultsKey(ns, name string) string {
 	return fmt.Sprintf("%s/%s", ns, name)
 }
 
-func (b *backupper) BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.Pod, log logrus.FieldLogger) (map[string]string, []error) {
+func (b *backupper) BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.Pod, log logrus.FieldLogger) (*PodVolumeBackupResult, []error) {
+	result, errs := b.backupPodVolumes(backup, pod, log)
+	if result != nil {
+		recordVolumeFailures(b.repoManager.eventRecorder, objectReference("Backup", backup), VolumeBackupFailedReason, pod, result.Volumes)
+	}
+	return result, errs
+}
+
+func (b *backupper) backupPodVolumes(backup *velerov1api.Backup, pod *corev1api.Pod, log logrus.FieldLogger) (*PodVolumeBackupResult, []error) {
 	// get volumes to backup from pod's annotations
 	volumesToBackup := GetVolumesToBackup(pod)
 	if len(volumesToBackup) == 0 {
 		return nil, nil
 	}
 
+	if b.namespaceDisabled(pod.Namespace, log) {
+		log.Infof("Not backing up volumes %v of pod %s/%s because restic backup is disabled for its namespace by the %s annotation", volumesToBackup, pod.Namespace, pod.Name, BackupNamespaceAnnotation)
+		result := &PodVolumeBackupResult{}
+		for _, volume := range volumesToBackup {
+			result.Volumes = append(result.Volumes, VolumeResult{Volume: volume, Status: VolumeResultSkipped, Message: namespaceDisabledMessage})
+		}
+		return result, nil
+	}
+
+	// PodVolumeBackups are processed by the restic daemonset pod on the pod's node,
+	// so if there isn't one, they'd never complete.
+	if err := ensureDaemonPodRunningOnNode(b.repoManager.podClient, b.repoManager.namespace, pod.Spec.NodeName); err != nil {
+		err = errors.Wrapf(err, "unable to back up volumes %v of pod %s/%s", volumesToBackup, pod.Namespace, pod.Name)
+		return failedBackupResult(volumesToBackup, err), []error{err}
+	}
+
 	repo, err := b.repoEnsurer.EnsureRepo(b.ctx, backup.Namespace, pod.Namespace, backup.Spec.StorageLocation)
 	if err != nil {
-		return nil, []error{err}
+		return failedBackupResult(volumesToBackup, err), []error{err}
 	}
 
 	// get a single non-exclusive lock since we'll wait for all individual
@@ -112,9 +189,15 @@ func (b *backupper) BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.
 	b.resultsLock.Unlock()
 
 	var (
-		errs            []error
-		volumeSnapshots = make(map[string]string)
-		podVolumes      = make(map[string]corev1api.Volume)
+		errs          []error
+		result        = &PodVolumeBackupResult{Volumes: make([]VolumeResult, len(volumesToBackup))}
+		volumeIndexes = make(map[string]int)
+		podVolumes    = make(map[string]corev1api.Volume)
+		// the PodVolumeBackups that haven't completed yet, and when they
+		// were created, by volume name.
+		pending    = make(map[string]*velerov1api.PodVolumeBackup)
+		startTimes = make(map[string]time.Time)
+		cancelled  bool
 	)
 
 	// put the pod's volumes in a map for efficient lookup below
@@ -122,51 +205,275 @@ func (b *backupper) BackupPodVolumes(backup *velerov1api.Backup, pod *corev1api.
 		podVolumes[podVolume.Name] = podVolume
 	}
 
-	for _, volumeName := range volumesToBackup {
-		if !volumeExists(podVolumes, volumeName) {
-			log.Warnf("No volume named %s found in pod %s/%s, skipping", volumeName, pod.Namespace, pod.Name)
+	for i, volumeName := range volumesToBackup {
+		volumeIndexes[volumeName] = i
+		volumeResult := &result.Volumes[i]
+		volumeResult.Volume = volumeName
+
+		if reason, level := b.volumeSkipReason(pod, podVolumes, volumeName, log); reason != "" {
+			if level == logrus.WarnLevel {
+				log.Warnf("Skipping volume %s in pod %s/%s: %s", volumeName, pod.Namespace, pod.Name, reason)
+			} else {
+				log.Infof("Skipping volume %s in pod %s/%s: %s", volumeName, pod.Namespace, pod.Name, reason)
+			}
+			volumeResult.Status, volumeResult.Message = VolumeResultSkipped, reason
 			continue
 		}
 
-		// hostPath volumes are not supported because they're not mounted into /var/lib/kubelet/pods, so our
-		// daemonset pod has no way to access their data.
-		if isHostPathVolume(podVolumes, volumeName) {
-			log.Warnf("Volume %s in pod %s/%s is a hostPath volume which is not supported for restic backup, skipping", volumeName, pod.Namespace, pod.Name)
+		// if this volume's PVC has already been backed up by another pod in this
+		// backup, reuse its snapshot rather than backing up the same data again.
+		if snapshotID := b.getPVCSnapshot(pod.Namespace, podVolumes[volumeName]); snapshotID != "" {
+			log.Infof("Volume %s in pod %s/%s uses a PVC that has already been backed up with restic, reusing snapshot %s", volumeName, pod.Namespace, pod.Name, snapshotID)
+			volumeResult.Status, volumeResult.SnapshotID = VolumeResultCompleted, snapshotID
 			continue
 		}
 
-		volumeBackup := newPodVolumeBackup(backup, pod, volumeName, repo.Spec.ResticIdentifier)
-
-		if err := errorOnly(b.repoManager.veleroClient.VeleroV1().PodVolumeBackups(volumeBackup.Namespace).Create(volumeBackup)); err != nil {
+		volumeBackup, err := b.repoManager.veleroClient.VeleroV1().PodVolumeBackups(backup.Namespace).Create(newPodVolumeBackup(backup, pod, volumeName, repo.Spec.ResticIdentifier))
+		if err != nil {
 			errs = append(errs, err)
+			volumeResult.Status, volumeResult.Message = VolumeResultFailed, err.Error()
 			continue
 		}
 
-		volumeSnapshots[volumeName] = ""
+		pending[volumeName] = volumeBackup
+		startTimes[volumeName] = time.Now()
 	}
 
 ForEachVolume:
-	for i, count := 0, len(volumeSnapshots); i < count; i++ {
+	for len(pending) > 0 {
 		select {
 		case <-b.ctx.Done():
 			errs = append(errs, errors.New("timed out waiting for all PodVolumeBackups to complete"))
 			break ForEachVolume
 		case res := <-resultsChan:
+			// results for PodVolumeBackups that were cancelled below have
+			// already been recorded.
+			if _, ok := pending[res.Spec.Volume]; !ok {
+				continue
+			}
+			delete(pending, res.Spec.Volume)
+
+			volumeResult := &result.Volumes[volumeIndexes[res.Spec.Volume]]
+			volumeResult.Duration = time.Since(startTimes[res.Spec.Volume])
+			delete(startTimes, res.Spec.Volume)
+
 			switch res.Status.Phase {
 			case velerov1api.PodVolumeBackupPhaseCompleted:
-				volumeSnapshots[res.Spec.Volume] = res.Status.SnapshotID
+				volumeResult.Status, volumeResult.SnapshotID = VolumeResultCompleted, res.Status.SnapshotID
+				log.Infof("Backed up volume %s in pod %s/%s to snapshot %s: %d files processed, %d bytes added", res.Spec.Volume, pod.Namespace, pod.Name, res.Status.SnapshotID, res.Status.FilesProcessed, res.Status.BytesAdded)
+				b.setPVCSnapshot(pod.Namespace, podVolumes[res.Spec.Volume], res.Status.SnapshotID)
 			case velerov1api.PodVolumeBackupPhaseFailed:
 				errs = append(errs, errors.Errorf("pod volume backup failed: %s", res.Status.Message))
-				delete(volumeSnapshots, res.Spec.Volume)
+				volumeResult.Status, volumeResult.Message = VolumeResultFailed, res.Status.Message
+
+				if failFast(backup) && !cancelled {
+					log.Infof("Cancelling the rest of pod %s/%s's volume backups because %s is set", pod.Namespace, pod.Name, FailFastAnnotation)
+					b.cancelPendingBackups(pending, result, volumeIndexes, startTimes, log)
+					cancelled = true
+				}
 			}
 		}
 	}
 
-	b.resultsLock.Lock()
-	delete(b.results, resultsKey(pod.Namespace, pod.Name))
-	b.resultsLock.Unlock()
+	// the volumes whose backups haven't completed aren't recorded in the
+	// backup.
+	for volumeName, startTime := range startTimes {
+		volumeResult := &result.Volumes[volumeIndexes[volumeName]]
+		volumeResult.Status, volumeResult.Message = VolumeResultFailed, "stopped waiting for the volume's backup to complete"
+		volumeResult.Duration = time.Since(startTime)
+	}
 
-	return volumeSnapshots, errs
+	b.stopReceivingResults(pod, resultsChan)
+
+	return result, errs
+}
+
+// cancelPendingBackups cancels the pending PodVolumeBackups that the restic
+// daemonset hasn't started processing, and records them as skipped in result.
+// Ones that have started can't be cancelled, so they stay pending and are
+// waited for as usual, which keeps the repository locked while restic is
+// still using it.
+func (b *backupper) cancelPendingBackups(
+	pending map[string]*velerov1api.PodVolumeBackup,
+	result *PodVolumeBackupResult,
+	volumeIndexes map[string]int,
+	startTimes map[string]time.Time,
+	log logrus.FieldLogger,
+) {
+	for volumeName, pvb := range pending {
+		cancelled, err := b.cancelPodVolumeBackup(pvb)
+		if err != nil {
+			log.WithError(err).Warnf("Unable to cancel the backup of volume %s, waiting for it to complete", volumeName)
+			continue
+		}
+		if !cancelled {
+			log.Infof("The backup of volume %s has already started, waiting for it to complete", volumeName)
+			continue
+		}
+
+		volumeResult := &result.Volumes[volumeIndexes[volumeName]]
+		volumeResult.Status, volumeResult.Message = VolumeResultSkipped, podVolumeBackupCancelledMessage
+		volumeResult.Duration = time.Since(startTimes[volumeName])
+
+		delete(pending, volumeName)
+		delete(startTimes, volumeName)
+	}
+}
+
+// cancelPodVolumeBackup fails the PodVolumeBackup if its phase is still New,
+// and returns true if it did. The update is rejected if the PodVolumeBackup
+// has changed since it was retrieved, so it can't race with the restic
+// daemonset starting to process it.
+func (b *backupper) cancelPodVolumeBackup(pvb *velerov1api.PodVolumeBackup) (bool, error) {
+	client := b.repoManager.veleroClient.VeleroV1().PodVolumeBackups(pvb.Namespace)
+
+	current, err := client.Get(pvb.Name, metav1.GetOptions{})
+	if err != nil {
+		return false, errors.WithStack(err)
+	}
+
+	if current.Status.Phase != "" && current.Status.Phase != velerov1api.PodVolumeBackupPhaseNew {
+		return false, nil
+	}
+
+	current.Status.Phase = velerov1api.PodVolumeBackupPhaseFailed
+	current.Status.Message = podVolumeBackupCancelledMessage
+
+	if _, err := client.Update(current); err != nil {
+		if apierrors.IsConflict(err) {
+			return false, nil
+		}
+		return false, errors.WithStack(err)
+	}
+
+	return true, nil
+}
+
+// failedBackupResult returns a result with each of volumes failed with err.
+func failedBackupResult(volumes []string, err error) *PodVolumeBackupResult {
+	result := &PodVolumeBackupResult{}
+	for _, volume := range volumes {
+		result.Volumes = append(result.Volumes, VolumeResult{Volume: volume, Status: VolumeResultFailed, Message: err.Error()})
+	}
+	return result
+}
+
+// stopReceivingResults removes the pod's results channel so that no more
+// results are sent on it. If not all of the pod's results were received,
+// the informer's event handler may be blocked sending one while holding
+// resultsLock, so results are drained until the channel is removed.
+func (b *backupper) stopReceivingResults(pod *corev1api.Pod, resultsChan chan *velerov1api.PodVolumeBackup) {
+	removed := make(chan struct{})
+
+	go func() {
+		b.resultsLock.Lock()
+		delete(b.results, resultsKey(pod.Namespace, pod.Name))
+		b.resultsLock.Unlock()
+
+		close(removed)
+	}()
+
+	for {
+		select {
+		case <-resultsChan:
+		case <-removed:
+			return
+		}
+	}
+}
+
+// namespaceDisabled returns true if restic backup is disabled for the
+// namespace by its BackupNamespaceAnnotation. Each namespace is only looked
+// up once per backup. If it can't be looked up, backups aren't disabled for
+// the rest of the backup.
+func (b *backupper) namespaceDisabled(namespace string, log logrus.FieldLogger) bool {
+	b.disabledNamespacesLock.Lock()
+	defer b.disabledNamespacesLock.Unlock()
+
+	if disabled, ok := b.disabledNamespaces[namespace]; ok {
+		return disabled
+	}
+
+	ns, err := b.repoManager.namespaceClient.Namespaces().Get(namespace, metav1.GetOptions{})
+	if err != nil {
+		log.WithError(err).Warnf("Unable to get namespace %s to check whether restic backup is disabled for it", namespace)
+		b.disabledNamespaces[namespace] = false
+		return false
+	}
+
+	disabled := ns.Annotations[BackupNamespaceAnnotation] == "disabled"
+	b.disabledNamespaces[namespace] = disabled
+	return disabled
+}
+
+// namespaceDisabledMessage is the message of the results of volumes that
+// aren't backed up because restic backup is disabled for their pod's
+// namespace.
+const namespaceDisabledMessage = "restic backup is disabled for the pod's namespace"
+
+// podVolumeBackupCancelledMessage is the status message of PodVolumeBackups
+// that are cancelled because another of the pod's volume backups failed.
+const podVolumeBackupCancelledMessage = "cancelled because another of the pod's volume backups failed"
+
+// failFast returns true if the backup is annotated to stop waiting for a
+// pod's volume backups as soon as one of them fails.
+func failFast(backup *velerov1api.Backup) bool {
+	return backup.Annotations[FailFastAnnotation] == "true"
+}
+
+// ensureDaemonPodRunningOnNode returns an error if the specified node is empty or
+// there is no running restic daemonset pod on it.
+func ensureDaemonPodRunningOnNode(podClient corev1client.PodsGetter, namespace, node string) error {
+	if node == "" {
+		return errors.New("pod is not scheduled to a node")
+	}
+
+	pods, err := podClient.Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("name=%s", DaemonSet),
+		FieldSelector: fmt.Sprintf("spec.nodeName=%s", node),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error listing restic daemonset pods on node %s", node)
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == node && pod.Status.Phase == corev1api.PodRunning {
+			return nil
+		}
+	}
+
+	return errors.Errorf("no running restic daemonset pod found on node %s", node)
+}
+
+// getPVCSnapshot returns the ID of the snapshot taken in this backup of the
+// PVC used by the specified volume, or "" if the volume isn't a PVC or its PVC
+// hasn't been backed up yet.
+func (b *backupper) getPVCSnapshot(namespace string, volume corev1api.Volume) string {
+	if volume.PersistentVolumeClaim == nil {
+		return ""
+	}
+
+	b.pvcSnapshotsLock.Lock()
+	defer b.pvcSnapshotsLock.Unlock()
+
+	return b.pvcSnapshots[pvcKey(namespace, volume.PersistentVolumeClaim.ClaimName)]
+}
+
+// setPVCSnapshot records the ID of the snapshot taken of the PVC used by the
+// specified volume, if it is a PVC volume.
+func (b *backupper) setPVCSnapshot(namespace string, volume corev1api.Volume, snapshotID string) {
+	if volume.PersistentVolumeClaim == nil {
+		return
+	}
+
+	b.pvcSnapshotsLock.Lock()
+	defer b.pvcSnapshotsLock.Unlock()
+
+	b.pvcSnapshots[pvcKey(namespace, volume.PersistentVolumeClaim.ClaimName)] = snapshotID
+}
+
+func pvcKey(namespace, claimName string) string {
+	return fmt.Sprintf("%s/%s", namespace, claimName)
 }
 
 func volumeExists(podVolumes map[string]corev1api.Volume, volumeName string) bool {
@@ -183,8 +490,140 @@ func isHostPathVolume(podVolumes map[string]corev1api.Volume, volumeName string)
 	return volume.HostPath != nil
 }
 
+// volumeSkipReason returns why the pod's volume isn't backed up with restic,
+// or "" if it is, and the level to log the skip at. Skips caused by a
+// misconfigured pod annotation are logged as warnings. Both BackupPodVolumes
+// and PlanPodVolumeBackup use it, so that a plan always matches what a backup
+// does.
+func (b *backupper) volumeSkipReason(pod *corev1api.Pod, podVolumes map[string]corev1api.Volume, volumeName string, log logrus.FieldLogger) (string, logrus.Level) {
+	switch {
+	case b.namespaceDisabled(pod.Namespace, log):
+		return namespaceDisabledMessage, logrus.InfoLevel
+	case !volumeExists(podVolumes, volumeName):
+		return "volume not found in pod", logrus.WarnLevel
+	// hostPath volumes are not supported because they're not mounted into /var/lib/kubelet/pods, so our
+	// daemonset pod has no way to access their data.
+	case isHostPathVolume(podVolumes, volumeName):
+		return "hostPath volumes are not supported for restic backup", logrus.WarnLevel
+	case b.isNonBackupableVolume(pod, podVolumes[volumeName]):
+		return "volume contains ephemeral data that can't be usefully backed up with restic", logrus.InfoLevel
+	default:
+		return "", logrus.InfoLevel
+	}
+}
+
+func (b *backupper) isNonBackupableVolume(pod *corev1api.Pod, volume corev1api.Volume) bool {
+	return isNonBackupableVolume(b.nonBackupableVolumeFilters, pod, volume)
+}
+
+// isNonBackupableVolume returns true if the pod's volume matches any of the
+// filters.
+func isNonBackupableVolume(filters []volumeFilter, pod *corev1api.Pod, volume corev1api.Volume) bool {
+	for _, filter := range filters {
+		if filter(pod, volume) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateNonBackupableVolumes returns an error if any of the entries isn't
+// name=<volume name> or type=<volume type>, where the type is a volume source
+// as named in a pod spec, e.g. emptyDir.
+func ValidateNonBackupableVolumes(entries []string) error {
+	_, err := parseNonBackupableVolumes(entries)
+	return err
+}
+
+// parseNonBackupableVolumes returns a filter for each of the entries, which
+// are in the form accepted by ValidateNonBackupableVolumes.
+func parseNonBackupableVolumes(entries []string) ([]volumeFilter, error) {
+	var filters []volumeFilter
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry, nonBackupableVolumeNamePrefix) && entry != nonBackupableVolumeNamePrefix:
+			filters = append(filters, volumeNameFilter(strings.TrimPrefix(entry, nonBackupableVolumeNamePrefix)))
+		case strings.HasPrefix(entry, nonBackupableVolumeTypePrefix) && isVolumeType(strings.TrimPrefix(entry, nonBackupableVolumeTypePrefix)):
+			filters = append(filters, volumeTypeFilter(strings.TrimPrefix(entry, nonBackupableVolumeTypePrefix)))
+		default:
+			return nil, errors.Errorf("invalid non-backupable volume %q, expected name=<volume name> or type=<volume type>", entry)
+		}
+	}
+	return filters, nil
+}
+
+// volumeNameFilter returns a filter that matches volumes named name.
+func volumeNameFilter(name string) volumeFilter {
+	return func(_ *corev1api.Pod, volume corev1api.Volume) bool {
+		return volume.Name == name
+	}
+}
+
+// volumeTypeFilter returns a filter that matches volumes of volumeType.
+func volumeTypeFilter(volumeType string) volumeFilter {
+	return func(_ *corev1api.Pod, volume corev1api.Volume) bool {
+		return getVolumeType(volume) == volumeType
+	}
+}
+
+// getVolumeType returns the type of the volume's source as it's named in a
+// pod spec, e.g. emptyDir, or "" if it has no source.
+func getVolumeType(volume corev1api.Volume) string {
+	source := reflect.ValueOf(volume.VolumeSource)
+	for i := 0; i < source.NumField(); i++ {
+		if !source.Field(i).IsNil() {
+			return volumeSourceFieldName(source.Type().Field(i))
+		}
+	}
+	return ""
+}
+
+// isVolumeType returns true if volumeType is the name of a volume source in
+// a pod spec.
+func isVolumeType(volumeType string) bool {
+	sourceType := reflect.TypeOf(corev1api.VolumeSource{})
+	for i := 0; i < sourceType.NumField(); i++ {
+		if volumeSourceFieldName(sourceType.Field(i)) == volumeType {
+			return true
+		}
+	}
+	return false
+}
+
+// volumeSourceFieldName returns the name of a VolumeSource field in JSON.
+func volumeSourceFieldName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// isServiceAccountTokenVolume returns true if the volume contains a service
+// account token, either because it's a projected volume with a service account
+// token source, or because it's mounted at the path where the default service
+// account token secret is mounted. Tokens are rotated, so backing them up
+// produces a new snapshot every time and is never useful to restore.
+func isServiceAccountTokenVolume(pod *corev1api.Pod, volume corev1api.Volume) bool {
+	if volume.Projected != nil {
+		for _, source := range volume.Projected.Sources {
+			if source.ServiceAccountToken != nil {
+				return true
+			}
+		}
+	}
+
+	if volume.Secret != nil {
+		for _, container := range pod.Spec.Containers {
+			for _, mount := range container.VolumeMounts {
+				if mount.Name == volume.Name && mount.MountPath == serviceAccountTokenMountPath {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
 func newPodVolumeBackup(backup *velerov1api.Backup, pod *corev1api.Pod, volumeName, repoIdentifier string) *velerov1api.PodVolumeBackup {
-	return &velerov1api.PodVolumeBackup{
+	pvb := &velerov1api.PodVolumeBackup{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace:    backup.Namespace,
 			GenerateName: backup.Name + "-",
@@ -223,6 +662,19 @@ func newPodVolumeBackup(backup *velerov1api.Backup, pod *corev1api.Pod, volumeNa
 			RepoIdentifier:        repoIdentifier,
 		},
 	}
+
+	// pass the backup's max volume size and one-file-system settings, if
+	// any, on to the restic daemonset pod that processes the PodVolumeBackup.
+	for _, annotation := range []string{MaxVolumeSizeAnnotation, OneFileSystemAnnotation} {
+		if val, ok := backup.Annotations[annotation]; ok {
+			if pvb.Annotations == nil {
+				pvb.Annotations = make(map[string]string)
+			}
+			pvb.Annotations[annotation] = val
+		}
+	}
+
+	return pvb
 }
 
 func errorOnly(_ interface{}, err error) error {
@@ -17,10 +17,25 @@ limitations under the License.
 package restic
 
 import (
+	"context"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/scheme"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	velerotest "github.com/heptio/velero/pkg/util/test"
 )
 
 func TestVolumeExists(t *testing.T) {
@@ -48,3 +63,711 @@ func TestIsHostPathVolume(t *testing.T) {
 	assert.False(t, isHostPathVolume(podVolumes, "bar"))
 	assert.False(t, isHostPathVolume(podVolumes, "non-existent volume"))
 }
+
+func TestEnsureDaemonPodRunningOnNode(t *testing.T) {
+	tests := []struct {
+		name        string
+		node        string
+		pods        []corev1api.Pod
+		listErr     error
+		expectedErr string
+	}{
+		{
+			name:        "empty node name returns an error",
+			node:        "",
+			expectedErr: "pod is not scheduled to a node",
+		},
+		{
+			name:        "no daemonset pods on node returns an error",
+			node:        "node-1",
+			expectedErr: "no running restic daemonset pod found on node node-1",
+		},
+		{
+			name: "daemonset pod on node that is not running returns an error",
+			node: "node-1",
+			pods: []corev1api.Pod{
+				{
+					Spec:   corev1api.PodSpec{NodeName: "node-1"},
+					Status: corev1api.PodStatus{Phase: corev1api.PodPending},
+				},
+			},
+			expectedErr: "no running restic daemonset pod found on node node-1",
+		},
+		{
+			name: "running daemonset pod on node returns no error",
+			node: "node-1",
+			pods: []corev1api.Pod{
+				{
+					Spec:   corev1api.PodSpec{NodeName: "node-1"},
+					Status: corev1api.PodStatus{Phase: corev1api.PodRunning},
+				},
+			},
+		},
+		{
+			name:        "error listing pods is returned",
+			node:        "node-1",
+			listErr:     errors.New("list error"),
+			expectedErr: "error listing restic daemonset pods on node node-1: list error",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &fakePodClient{pods: test.pods, err: test.listErr}
+
+			err := ensureDaemonPodRunningOnNode(client, "velero", test.node)
+
+			if test.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, test.expectedErr)
+			}
+		})
+	}
+}
+
+type fakePodClient struct {
+	corev1client.PodInterface
+
+	pods []corev1api.Pod
+	err  error
+}
+
+func (c *fakePodClient) Pods(namespace string) corev1client.PodInterface {
+	return c
+}
+
+func (c *fakePodClient) List(opts metav1.ListOptions) (*corev1api.PodList, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &corev1api.PodList{Items: c.pods}, nil
+}
+
+// fakeNamespaceClient is a NamespacesGetter whose Get returns err if it's
+// set, or the namespace with the requested name from namespaces, or a
+// namespace with no annotations if it's not there. It counts how often Get
+// is called.
+type fakeNamespaceClient struct {
+	corev1client.NamespaceInterface
+
+	namespaces []corev1api.Namespace
+	err        error
+	getCalls   int
+}
+
+func (c *fakeNamespaceClient) Namespaces() corev1client.NamespaceInterface {
+	return c
+}
+
+func (c *fakeNamespaceClient) Get(name string, opts metav1.GetOptions) (*corev1api.Namespace, error) {
+	c.getCalls++
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	for i := range c.namespaces {
+		if c.namespaces[i].Name == name {
+			return &c.namespaces[i], nil
+		}
+	}
+	return &corev1api.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+}
+
+// newTestBackupper returns a backupper that creates PodVolumeBackups with
+// client. The restic daemonset pod is running on node-1, and ns-1's restic
+// repository for the default backup storage location is ready.
+func newTestBackupper(t *testing.T, client *fake.Clientset) *backupper {
+	var (
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		repoInformer    = sharedInformers.Velero().V1().ResticRepositories()
+	)
+
+	repo := &velerov1api.ResticRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      "repo-1",
+			Labels:    repoLabels("ns-1", "default"),
+		},
+		Status: velerov1api.ResticRepositoryStatus{
+			Phase: velerov1api.ResticRepositoryPhaseReady,
+		},
+	}
+	require.NoError(t, repoInformer.Informer().GetStore().Add(repo))
+
+	return &backupper{
+		ctx: context.Background(),
+		repoManager: &repositoryManager{
+			namespace:    "velero",
+			veleroClient: client,
+			podClient: &fakePodClient{
+				pods: []corev1api.Pod{
+					{
+						Spec:   corev1api.PodSpec{NodeName: "node-1"},
+						Status: corev1api.PodStatus{Phase: corev1api.PodRunning},
+					},
+				},
+			},
+			namespaceClient: &fakeNamespaceClient{},
+			eventRecorder:   &fakeEventRecorder{},
+			repoLocker:      newRepoLocker(),
+		},
+		repoEnsurer:                newRepositoryEnsurer(repoInformer, client.VeleroV1(), velerotest.NewLogger()),
+		results:                    make(map[string]chan *velerov1api.PodVolumeBackup),
+		pvcSnapshots:               make(map[string]string),
+		disabledNamespaces:         make(map[string]bool),
+		nonBackupableVolumeFilters: defaultNonBackupableVolumeFilters,
+	}
+}
+
+// newTestBackup returns a backup, to the default backup storage location,
+// for newTestBackupper.
+func newTestBackup() *velerov1api.Backup {
+	backup := velerotest.NewTestBackup().WithNamespace("velero").WithName("backup-1").Backup
+	backup.Spec.StorageLocation = "default"
+	return backup
+}
+
+// newTestPod returns ns-1/pod-1, running on node-1 with volumes, annotated to
+// back up the comma-separated volumesToBackup.
+func newTestPod(volumesToBackup string, volumes ...corev1api.Volume) *corev1api.Pod {
+	return &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   "ns-1",
+			Name:        "pod-1",
+			Annotations: map[string]string{volumesToBackupAnnotation: volumesToBackup},
+		},
+		Spec: corev1api.PodSpec{
+			NodeName: "node-1",
+			Volumes:  volumes,
+		},
+	}
+}
+
+func TestBackupPodVolumesSkipsDisabledNamespace(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	b := newTestBackupper(t, client)
+	b.repoManager.namespaceClient = &fakeNamespaceClient{
+		namespaces: []corev1api.Namespace{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "ns-1",
+					Annotations: map[string]string{BackupNamespaceAnnotation: "disabled"},
+				},
+			},
+		},
+	}
+
+	pod := newTestPod("vol-1,vol-2", corev1api.Volume{Name: "vol-1"}, corev1api.Volume{Name: "vol-2"})
+
+	result, errs := b.BackupPodVolumes(newTestBackup(), pod, velerotest.NewLogger())
+
+	assert.Empty(t, errs)
+	require.NotNil(t, result)
+	assert.Equal(t, []VolumeResult{
+		{Volume: "vol-1", Status: VolumeResultSkipped, Message: "restic backup is disabled for the pod's namespace"},
+		{Volume: "vol-2", Status: VolumeResultSkipped, Message: "restic backup is disabled for the pod's namespace"},
+	}, result.Volumes)
+	assert.Empty(t, result.Snapshots())
+
+	podVolumeBackups, err := client.VeleroV1().PodVolumeBackups("velero").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, podVolumeBackups.Items)
+}
+
+func TestNamespaceDisabledCachesLookupFailures(t *testing.T) {
+	namespaceClient := &fakeNamespaceClient{err: errors.New("get error")}
+
+	b := newTestBackupper(t, fake.NewSimpleClientset())
+	b.repoManager.namespaceClient = namespaceClient
+
+	// restic backup isn't disabled for a namespace that can't be looked up,
+	// and it's only looked up once.
+	for i := 0; i < 3; i++ {
+		assert.False(t, b.namespaceDisabled("ns-1", velerotest.NewLogger()))
+	}
+	assert.Equal(t, 1, namespaceClient.getCalls)
+}
+
+func TestBackupPodVolumesReusesPVCSnapshots(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	b := newTestBackupper(t, client)
+	b.pvcSnapshots["ns-1/pvc-1"] = "snapshot-1"
+
+	pod := newTestPod("vol-1", corev1api.Volume{
+		Name: "vol-1",
+		VolumeSource: corev1api.VolumeSource{
+			PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+		},
+	})
+
+	result, errs := b.BackupPodVolumes(newTestBackup(), pod, velerotest.NewLogger())
+
+	assert.Empty(t, errs)
+	assert.Equal(t, map[string]string{"vol-1": "snapshot-1"}, result.Snapshots())
+
+	podVolumeBackups, err := client.VeleroV1().PodVolumeBackups("velero").List(metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, podVolumeBackups.Items)
+}
+
+func TestBackupPodVolumesFailFast(t *testing.T) {
+	tests := []struct {
+		name string
+		// vol2Started is whether the restic daemonset has started
+		// processing vol-2's PodVolumeBackup when vol-1's fails.
+		vol2Started          bool
+		expectedVol2Result   VolumeResult
+		expectedVol2PVBPhase velerov1api.PodVolumeBackupPhase
+	}{
+		{
+			name:                 "backup that hasn't started is cancelled",
+			expectedVol2Result:   VolumeResult{Volume: "vol-2", Status: VolumeResultSkipped, Message: podVolumeBackupCancelledMessage},
+			expectedVol2PVBPhase: velerov1api.PodVolumeBackupPhaseFailed,
+		},
+		{
+			name:                 "backup that has started is waited for",
+			vol2Started:          true,
+			expectedVol2Result:   VolumeResult{Volume: "vol-2", Status: VolumeResultCompleted, SnapshotID: "snapshot-2"},
+			expectedVol2PVBPhase: velerov1api.PodVolumeBackupPhaseInProgress,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+
+			// the fake clientset doesn't support generateName, so keep the
+			// PodVolumeBackups in a separate tracker, named after their
+			// volumes.
+			tracker := core.NewObjectTracker(scheme.Scheme, scheme.Codecs.UniversalDecoder())
+			client.PrependReactor("*", "podvolumebackups", func(action core.Action) (bool, runtime.Object, error) {
+				if create, ok := action.(core.CreateAction); ok {
+					pvb := create.GetObject().(*velerov1api.PodVolumeBackup)
+					pvb.Name = pvb.GenerateName + pvb.Spec.Volume
+				}
+				return core.ObjectReaction(tracker)(action)
+			})
+
+			// the context is never cancelled, so if BackupPodVolumes waits
+			// for vol-2's backup after cancelling it, the test times out.
+			b := newTestBackupper(t, client)
+
+			backup := newTestBackup()
+			backup.Annotations = map[string]string{FailFastAnnotation: "true"}
+
+			pod := newTestPod("vol-1,vol-2", corev1api.Volume{Name: "vol-1"}, corev1api.Volume{Name: "vol-2"})
+
+			// send the results the way the informer's event handler would,
+			// once BackupPodVolumes is waiting for results.
+			go func() {
+				for {
+					b.resultsLock.Lock()
+					resultsChan, ok := b.results[resultsKey(pod.Namespace, pod.Name)]
+					b.resultsLock.Unlock()
+
+					if ok {
+						// the PodVolumeBackups are created before results are
+						// received, so vol-2's exists once vol-1's is sent.
+						if test.vol2Started {
+							pvb, err := client.VeleroV1().PodVolumeBackups("velero").Get("backup-1-vol-2", metav1.GetOptions{})
+							if err != nil {
+								time.Sleep(10 * time.Millisecond)
+								continue
+							}
+							pvb.Status.Phase = velerov1api.PodVolumeBackupPhaseInProgress
+							_, _ = client.VeleroV1().PodVolumeBackups("velero").Update(pvb)
+						}
+
+						resultsChan <- &velerov1api.PodVolumeBackup{
+							Spec: velerov1api.PodVolumeBackupSpec{Volume: "vol-1"},
+							Status: velerov1api.PodVolumeBackupStatus{
+								Phase:   velerov1api.PodVolumeBackupPhaseFailed,
+								Message: "restic error",
+							},
+						}
+
+						if test.vol2Started {
+							resultsChan <- &velerov1api.PodVolumeBackup{
+								Spec: velerov1api.PodVolumeBackupSpec{Volume: "vol-2"},
+								Status: velerov1api.PodVolumeBackupStatus{
+									Phase:      velerov1api.PodVolumeBackupPhaseCompleted,
+									SnapshotID: "snapshot-2",
+								},
+							}
+						}
+						return
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+			}()
+
+			result, errs := b.BackupPodVolumes(backup, pod, velerotest.NewLogger())
+
+			require.Len(t, errs, 1)
+			assert.EqualError(t, errs[0], "pod volume backup failed: restic error")
+			require.NotNil(t, result)
+			require.Len(t, result.Volumes, 2)
+
+			assert.Equal(t, VolumeResultFailed, result.Volumes[0].Status)
+			vol2Result := result.Volumes[1]
+			vol2Result.Duration = 0
+			assert.Equal(t, test.expectedVol2Result, vol2Result)
+
+			pvb, err := client.VeleroV1().PodVolumeBackups("velero").Get("backup-1-vol-2", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedVol2PVBPhase, pvb.Status.Phase)
+			if !test.vol2Started {
+				assert.Equal(t, podVolumeBackupCancelledMessage, pvb.Status.Message)
+			}
+		})
+	}
+}
+
+func TestBackupPodVolumesResult(t *testing.T) {
+	var (
+		client   = fake.NewSimpleClientset()
+		recorder = &fakeEventRecorder{}
+	)
+
+	client.PrependReactor("create", "podvolumebackups", func(action core.Action) (bool, runtime.Object, error) {
+		// the fake clientset doesn't support generateName, so don't
+		// pass the create through to it.
+		return true, action.(core.CreateAction).GetObject(), nil
+	})
+
+	b := newTestBackupper(t, client)
+	b.repoManager.eventRecorder = recorder
+
+	backup := newTestBackup()
+
+	pod := newTestPod("vol-1,vol-2,host-path,missing",
+		corev1api.Volume{Name: "vol-1"},
+		corev1api.Volume{Name: "vol-2"},
+		corev1api.Volume{
+			Name: "host-path",
+			VolumeSource: corev1api.VolumeSource{
+				HostPath: &corev1api.HostPathVolumeSource{Path: "/tmp"},
+			},
+		},
+	)
+
+	// send vol-1's and vol-2's results the way the informer's event
+	// handler would, once BackupPodVolumes is waiting for results.
+	go func() {
+		for {
+			b.resultsLock.Lock()
+			resultsChan, ok := b.results[resultsKey(pod.Namespace, pod.Name)]
+			b.resultsLock.Unlock()
+
+			if ok {
+				resultsChan <- &velerov1api.PodVolumeBackup{
+					Spec: velerov1api.PodVolumeBackupSpec{Volume: "vol-2"},
+					Status: velerov1api.PodVolumeBackupStatus{
+						Phase:   velerov1api.PodVolumeBackupPhaseFailed,
+						Message: "restic error",
+					},
+				}
+				resultsChan <- &velerov1api.PodVolumeBackup{
+					Spec: velerov1api.PodVolumeBackupSpec{Volume: "vol-1"},
+					Status: velerov1api.PodVolumeBackupStatus{
+						Phase:      velerov1api.PodVolumeBackupPhaseCompleted,
+						SnapshotID: "snapshot-1",
+					},
+				}
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	result, errs := b.BackupPodVolumes(backup, pod, velerotest.NewLogger())
+
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], "pod volume backup failed: restic error")
+
+	require.NotNil(t, result)
+	for i := range result.Volumes {
+		assert.True(t, result.Volumes[i].Duration >= 0)
+		result.Volumes[i].Duration = 0
+	}
+	assert.Equal(t, []VolumeResult{
+		{Volume: "vol-1", Status: VolumeResultCompleted, SnapshotID: "snapshot-1"},
+		{Volume: "vol-2", Status: VolumeResultFailed, Message: "restic error"},
+		{Volume: "host-path", Status: VolumeResultSkipped, Message: "hostPath volumes are not supported for restic backup"},
+		{Volume: "missing", Status: VolumeResultSkipped, Message: "volume not found in pod"},
+	}, result.Volumes)
+	assert.Equal(t, map[string]string{"vol-1": "snapshot-1"}, result.Snapshots())
+
+	// only the failed volume has an event recorded on the backup.
+	assert.Equal(t, []recordedEvent{
+		{
+			ref:       objectReference("Backup", backup),
+			eventType: corev1api.EventTypeWarning,
+			reason:    VolumeBackupFailedReason,
+			message:   "Failed to back up volume vol-2 of pod ns-1/pod-1 with restic: restic error",
+		},
+	}, recorder.events)
+}
+
+func TestVolumeSkipReason(t *testing.T) {
+	b := newTestBackupper(t, fake.NewSimpleClientset())
+
+	pod := newTestPod("",
+		corev1api.Volume{Name: "vol-1"},
+		corev1api.Volume{
+			Name: "host-path",
+			VolumeSource: corev1api.VolumeSource{
+				HostPath: &corev1api.HostPathVolumeSource{Path: "/tmp"},
+			},
+		},
+		corev1api.Volume{
+			Name: "token",
+			VolumeSource: corev1api.VolumeSource{
+				Projected: &corev1api.ProjectedVolumeSource{
+					Sources: []corev1api.VolumeProjection{
+						{ServiceAccountToken: &corev1api.ServiceAccountTokenProjection{Path: "token"}},
+					},
+				},
+			},
+		},
+	)
+	podVolumes := make(map[string]corev1api.Volume)
+	for _, volume := range pod.Spec.Volumes {
+		podVolumes[volume.Name] = volume
+	}
+
+	tests := []struct {
+		volume         string
+		expectedReason string
+		expectedLevel  logrus.Level
+	}{
+		{volume: "vol-1", expectedReason: "", expectedLevel: logrus.InfoLevel},
+		// skips caused by misconfigured annotations are warnings.
+		{volume: "missing", expectedReason: "volume not found in pod", expectedLevel: logrus.WarnLevel},
+		{volume: "host-path", expectedReason: "hostPath volumes are not supported for restic backup", expectedLevel: logrus.WarnLevel},
+		{volume: "token", expectedReason: "volume contains ephemeral data that can't be usefully backed up with restic", expectedLevel: logrus.InfoLevel},
+	}
+
+	for _, test := range tests {
+		t.Run(test.volume, func(t *testing.T) {
+			reason, level := b.volumeSkipReason(pod, podVolumes, test.volume, velerotest.NewLogger())
+
+			assert.Equal(t, test.expectedReason, reason)
+			assert.Equal(t, test.expectedLevel, level)
+		})
+	}
+}
+
+func TestPVCSnapshots(t *testing.T) {
+	b := &backupper{pvcSnapshots: make(map[string]string)}
+
+	pvcVolume := corev1api.Volume{
+		Name: "vol-1",
+		VolumeSource: corev1api.VolumeSource{
+			PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+		},
+	}
+	emptyDirVolume := corev1api.Volume{
+		Name: "vol-2",
+		VolumeSource: corev1api.VolumeSource{
+			EmptyDir: &corev1api.EmptyDirVolumeSource{},
+		},
+	}
+
+	assert.Equal(t, "", b.getPVCSnapshot("ns-1", pvcVolume))
+
+	b.setPVCSnapshot("ns-1", pvcVolume, "snapshot-1")
+	b.setPVCSnapshot("ns-1", emptyDirVolume, "snapshot-2")
+
+	assert.Equal(t, "snapshot-1", b.getPVCSnapshot("ns-1", pvcVolume))
+	assert.Equal(t, "", b.getPVCSnapshot("ns-2", pvcVolume))
+	assert.Equal(t, "", b.getPVCSnapshot("ns-1", emptyDirVolume))
+	assert.Equal(t, map[string]string{"ns-1/pvc-1": "snapshot-1"}, b.pvcSnapshots)
+}
+
+func TestIsServiceAccountTokenVolume(t *testing.T) {
+	pod := &corev1api.Pod{
+		Spec: corev1api.PodSpec{
+			Containers: []corev1api.Container{
+				{
+					VolumeMounts: []corev1api.VolumeMount{
+						{Name: "default-token-abcde", MountPath: serviceAccountTokenMountPath},
+						{Name: "my-secret", MountPath: "/etc/my-secret"},
+					},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		volume   corev1api.Volume
+		expected bool
+	}{
+		{
+			name: "projected service account token volume",
+			volume: corev1api.Volume{
+				Name: "token",
+				VolumeSource: corev1api.VolumeSource{
+					Projected: &corev1api.ProjectedVolumeSource{
+						Sources: []corev1api.VolumeProjection{
+							{ServiceAccountToken: &corev1api.ServiceAccountTokenProjection{Path: "token"}},
+						},
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "projected volume without a service account token",
+			volume: corev1api.Volume{
+				Name: "projected",
+				VolumeSource: corev1api.VolumeSource{
+					Projected: &corev1api.ProjectedVolumeSource{
+						Sources: []corev1api.VolumeProjection{
+							{ConfigMap: &corev1api.ConfigMapProjection{}},
+						},
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "default token secret volume",
+			volume: corev1api.Volume{
+				Name:         "default-token-abcde",
+				VolumeSource: corev1api.VolumeSource{Secret: &corev1api.SecretVolumeSource{SecretName: "default-token-abcde"}},
+			},
+			expected: true,
+		},
+		{
+			name: "other secret volume",
+			volume: corev1api.Volume{
+				Name:         "my-secret",
+				VolumeSource: corev1api.VolumeSource{Secret: &corev1api.SecretVolumeSource{SecretName: "my-secret"}},
+			},
+			expected: false,
+		},
+		{
+			name: "emptyDir volume",
+			volume: corev1api.Volume{
+				Name:         "scratch",
+				VolumeSource: corev1api.VolumeSource{EmptyDir: &corev1api.EmptyDirVolumeSource{}},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, isServiceAccountTokenVolume(pod, test.volume))
+		})
+	}
+}
+
+func TestParseNonBackupableVolumes(t *testing.T) {
+	pod := &corev1api.Pod{}
+	cacheVolume := corev1api.Volume{Name: "cache", VolumeSource: corev1api.VolumeSource{EmptyDir: &corev1api.EmptyDirVolumeSource{}}}
+	dataVolume := corev1api.Volume{Name: "data", VolumeSource: corev1api.VolumeSource{PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"}}}
+	configVolume := corev1api.Volume{Name: "config", VolumeSource: corev1api.VolumeSource{ConfigMap: &corev1api.ConfigMapVolumeSource{}}}
+
+	tests := []struct {
+		name                string
+		entries             []string
+		expectedErr         bool
+		expectNonBackupable []corev1api.Volume
+		expectBackupable    []corev1api.Volume
+	}{
+		{
+			name:             "no entries match no volumes",
+			expectBackupable: []corev1api.Volume{cacheVolume, dataVolume, configVolume},
+		},
+		{
+			name:                "name entry matches volumes with that name",
+			entries:             []string{"name=cache"},
+			expectNonBackupable: []corev1api.Volume{cacheVolume},
+			expectBackupable:    []corev1api.Volume{dataVolume, configVolume},
+		},
+		{
+			name:                "type entries match volumes of those types",
+			entries:             []string{"type=emptyDir", "type=configMap"},
+			expectNonBackupable: []corev1api.Volume{cacheVolume, configVolume},
+			expectBackupable:    []corev1api.Volume{dataVolume},
+		},
+		{
+			name:        "unknown volume type returns an error",
+			entries:     []string{"type=emptydir"},
+			expectedErr: true,
+		},
+		{
+			name:        "empty volume name returns an error",
+			entries:     []string{"name="},
+			expectedErr: true,
+		},
+		{
+			name:        "entry without a prefix returns an error",
+			entries:     []string{"cache"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filters, err := parseNonBackupableVolumes(test.entries)
+			assert.Equal(t, test.expectedErr, ValidateNonBackupableVolumes(test.entries) != nil)
+
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, volume := range test.expectNonBackupable {
+				assert.True(t, isNonBackupableVolume(filters, pod, volume), "volume %s", volume.Name)
+			}
+			for _, volume := range test.expectBackupable {
+				assert.False(t, isNonBackupableVolume(filters, pod, volume), "volume %s", volume.Name)
+			}
+		})
+	}
+}
+
+func TestBackupperUsesConfiguredNonBackupableVolumes(t *testing.T) {
+	filters, err := parseNonBackupableVolumes([]string{"type=emptyDir"})
+	require.NoError(t, err)
+
+	b := newBackupper(
+		context.Background(),
+		&repositoryManager{nonBackupableVolumeFilters: filters},
+		nil,
+		informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0).Velero().V1().PodVolumeBackups().Informer(),
+		velerotest.NewLogger(),
+	)
+
+	pod := &corev1api.Pod{
+		Spec: corev1api.PodSpec{
+			Volumes: []corev1api.Volume{
+				{
+					Name: "token",
+					VolumeSource: corev1api.VolumeSource{
+						Projected: &corev1api.ProjectedVolumeSource{
+							Sources: []corev1api.VolumeProjection{
+								{ServiceAccountToken: &corev1api.ServiceAccountTokenProjection{Path: "token"}},
+							},
+						},
+					},
+				},
+				{Name: "cache", VolumeSource: corev1api.VolumeSource{EmptyDir: &corev1api.EmptyDirVolumeSource{}}},
+				{Name: "data", VolumeSource: corev1api.VolumeSource{PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"}}},
+			},
+		},
+	}
+
+	// the configured filters are used along with the default ones.
+	assert.True(t, b.isNonBackupableVolume(pod, pod.Spec.Volumes[0]))
+	assert.True(t, b.isNonBackupableVolume(pod, pod.Spec.Volumes[1]))
+	assert.False(t, b.isNonBackupableVolume(pod, pod.Spec.Volumes[2]))
+}
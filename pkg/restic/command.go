@@ -47,7 +47,12 @@ func (c *Command) RepoName() string {
 func (c *Command) StringSlice() []string {
 	res := []string{"restic"}
 
-	res = append(res, c.Command, repoFlag(c.RepoIdentifier))
+	res = append(res, c.Command)
+	// commands that don't operate on a repository, such as 'restic
+	// version', have no repo identifier.
+	if c.RepoIdentifier != "" {
+		res = append(res, repoFlag(c.RepoIdentifier))
+	}
 	if c.PasswordFile != "" {
 		res = append(res, passwordFlag(c.PasswordFile))
 	}
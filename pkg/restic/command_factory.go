@@ -18,23 +18,56 @@ package restic
 
 import (
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
+// snapshotHost is the host recorded on, and used to look up, all restic
+// snapshots taken by Velero. restic uses the host to find a parent snapshot,
+// and by default it would be the name of the daemonset pod where the `restic
+// backup` command is run. Using a single generic value means that backups of a
+// volume stay incremental when that pod is recreated or when the volume's pod
+// moves to another node, and that snapshots from all nodes share one host.
+const snapshotHost = "velero"
+
+const (
+	// minPackSize and maxPackSize are the range of pack sizes, in MiB, that
+	// restic accepts for its --pack-size flag.
+	minPackSize = 4
+	maxPackSize = 128
+
+	// packSizeMinVersion is the first restic version with the --pack-size
+	// flag.
+	packSizeMinVersion = "0.14.0"
+
+	// compressionMinVersion is the first restic version with the
+	// --compression flag and repository format version 2, which is required
+	// for compression.
+	compressionMinVersion = "0.14.0"
+
+	// readDataSubsetPercentMinVersion is the first restic version whose
+	// --read-data-subset flag accepts a percentage. Earlier versions only
+	// accept the n/t form, which reads the nth of t subsets of the data.
+	readDataSubsetPercentMinVersion = "0.12.0"
+)
+
+// validCompressionModes are the values that restic accepts for its
+// --compression flag.
+var validCompressionModes = []string{"auto", "off", "max"}
+
 // BackupCommand returns a Command for running a restic backup.
 func BackupCommand(repoIdentifier, passwordFile, path string, tags map[string]string) *Command {
-	// --hostname flag is provided with a generic value because restic uses the hostname
-	// to find a parent snapshot, and by default it will be the name of the daemonset pod
-	// where the `restic backup` command is run. If this pod is recreated, we want to continue
-	// taking incremental backups rather than triggering a full one due to a new pod name.
-
 	return &Command{
 		Command:        "backup",
 		RepoIdentifier: repoIdentifier,
 		PasswordFile:   passwordFile,
 		Dir:            path,
 		Args:           []string{"."},
-		ExtraFlags:     append(backupTagFlags(tags), "--hostname=velero"),
+		ExtraFlags:     append(backupTagFlags(tags), fmt.Sprintf("--hostname=%s", snapshotHost), "--json"),
 	}
 }
 
@@ -64,7 +97,7 @@ func GetSnapshotCommand(repoIdentifier, passwordFile string, tags map[string]str
 		Command:        "snapshots",
 		RepoIdentifier: repoIdentifier,
 		PasswordFile:   passwordFile,
-		ExtraFlags:     []string{"--json", "--last", getSnapshotTagFlag(tags)},
+		ExtraFlags:     []string{"--json", "--last", getSnapshotTagFlag(tags), fmt.Sprintf("--host=%s", snapshotHost)},
 	}
 }
 
@@ -84,11 +117,47 @@ func InitCommand(repoIdentifier string) *Command {
 	}
 }
 
-func CheckCommand(repoIdentifier string) *Command {
-	return &Command{
+// CheckCommand returns a Command for checking a restic repository for errors.
+// readDataPercent is the percentage of the repository's data that's read and
+// verified as well: none if it's 0, and all of it if it's 100. restic versions
+// before 0.12.0 can't read a percentage of the data, so for them a random one
+// of 100/readDataPercent equal subsets of it, rounded, is read instead.
+func CheckCommand(repoIdentifier string, readDataPercent int, resticVersion string) *Command {
+	cmd := &Command{
 		Command:        "check",
 		RepoIdentifier: repoIdentifier,
 	}
+
+	switch {
+	case readDataPercent <= 0:
+	case readDataPercent >= 100:
+		cmd.ExtraFlags = []string{"--read-data"}
+	case versionAtLeast(resticVersion, readDataSubsetPercentMinVersion):
+		cmd.ExtraFlags = []string{fmt.Sprintf("--read-data-subset=%d%%", readDataPercent)}
+	default:
+		subsets := int(math.Round(100 / float64(readDataPercent)))
+		if subsets <= 1 {
+			cmd.ExtraFlags = []string{"--read-data"}
+			break
+		}
+
+		// restic numbers subsets from 1.
+		subset := int(time.Now().UnixNano()%int64(subsets)) + 1
+		cmd.ExtraFlags = []string{fmt.Sprintf("--read-data-subset=%d/%d", subset, subsets)}
+	}
+
+	return cmd
+}
+
+// ListSnapshotsCommand returns a Command for listing the latest snapshots in
+// a restic repository, which requires opening the repo and taking a
+// non-exclusive lock on it.
+func ListSnapshotsCommand(repoIdentifier string) *Command {
+	return &Command{
+		Command:        "snapshots",
+		RepoIdentifier: repoIdentifier,
+		ExtraFlags:     []string{"--json", "--last"},
+	}
 }
 
 func PruneCommand(repoIdentifier string) *Command {
@@ -105,3 +174,147 @@ func ForgetCommand(repoIdentifier, snapshotID string) *Command {
 		Args:           []string{snapshotID},
 	}
 }
+
+// AddKeyCommand returns a Command for adding a key with the password in
+// newPasswordFile to a restic repository.
+func AddKeyCommand(repoIdentifier, newPasswordFile string) *Command {
+	return &Command{
+		Command:        "key",
+		RepoIdentifier: repoIdentifier,
+		Args:           []string{"add"},
+		ExtraFlags:     []string{fmt.Sprintf("--new-password-file=%s", newPasswordFile)},
+	}
+}
+
+// RemoveKeyCommand returns a Command for removing the key with the
+// specified ID from a restic repository.
+func RemoveKeyCommand(repoIdentifier, keyID string) *Command {
+	return &Command{
+		Command:        "key",
+		RepoIdentifier: repoIdentifier,
+		Args:           []string{"remove", keyID},
+	}
+}
+
+// VersionCommand returns a Command for running a restic version.
+func VersionCommand() *Command {
+	return &Command{
+		Command: "version",
+	}
+}
+
+// ValidatePackSize returns an error if packSize, in MiB, is outside of the
+// range restic accepts for its --pack-size flag.
+func ValidatePackSize(packSize int) error {
+	if packSize < minPackSize || packSize > maxPackSize {
+		return errors.Errorf("pack size must be between %d and %d MiB, got %d", minPackSize, maxPackSize, packSize)
+	}
+	return nil
+}
+
+// PackSizeFlags returns the flags for setting the pack size of restic backups
+// to packSize MiB, or none if packSize is zero or resticVersion doesn't support
+// setting the pack size.
+func PackSizeFlags(packSize int, resticVersion string) []string {
+	if packSize == 0 || !versionAtLeast(resticVersion, packSizeMinVersion) {
+		return nil
+	}
+	return []string{fmt.Sprintf("--pack-size=%d", packSize)}
+}
+
+// ValidateCompression returns an error if mode isn't one of the values that
+// restic accepts for its --compression flag.
+func ValidateCompression(mode string) error {
+	for _, valid := range validCompressionModes {
+		if mode == valid {
+			return nil
+		}
+	}
+	return errors.Errorf("compression mode must be one of %s, got %q", strings.Join(validCompressionModes, ", "), mode)
+}
+
+// ValidateReadDataPercent returns an error if percent isn't a percentage of a
+// repository's data that can be read when checking a repository.
+func ValidateReadDataPercent(percent int) error {
+	if percent < 0 || percent > 100 {
+		return errors.Errorf("read data percentage must be between 0 and 100, got %d", percent)
+	}
+	return nil
+}
+
+// CompressionFlags returns the flags for setting the compression mode of
+// restic backups to mode, or none if mode is empty or resticVersion doesn't
+// support compression.
+func CompressionFlags(mode, resticVersion string) []string {
+	if mode == "" || !versionAtLeast(resticVersion, compressionMinVersion) {
+		return nil
+	}
+	return []string{fmt.Sprintf("--compression=%s", mode)}
+}
+
+// OneFileSystemFlags returns the flags for keeping a restic backup on the
+// filesystem of the directory being backed up, or none if oneFileSystem is
+// false. restic doesn't back up the contents of other filesystems that are
+// mounted inside the directory, e.g. nested bind mounts, and backs up their
+// mount points as empty directories.
+func OneFileSystemFlags(oneFileSystem bool) []string {
+	if !oneFileSystem {
+		return nil
+	}
+	return []string{"--one-file-system"}
+}
+
+// InitRepositoryFlags returns the flags for initializing restic repositories
+// so that backups to them can be compressed with mode, or none if mode is empty
+// or "off", or resticVersion doesn't support compression.
+func InitRepositoryFlags(mode, resticVersion string) []string {
+	if mode == "" || mode == "off" || !versionAtLeast(resticVersion, compressionMinVersion) {
+		return nil
+	}
+	return []string{"--repository-version=2"}
+}
+
+// versionAtLeast returns true if version, in the form <major>.<minor>.<patch>
+// with an optional suffix, is at least minVersion. Versions that can't be
+// parsed are never at least minVersion.
+func versionAtLeast(version, minVersion string) bool {
+	v, ok := parseVersion(version)
+	if !ok {
+		return false
+	}
+	min, ok := parseVersion(minVersion)
+	if !ok {
+		return false
+	}
+
+	for i := range v {
+		if v[i] != min[i] {
+			return v[i] > min[i]
+		}
+	}
+	return true
+}
+
+func parseVersion(version string) ([3]int, bool) {
+	var res [3]int
+
+	// ignore any suffix, e.g. "-dev"
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if len(parts) != len(res) {
+		return res, false
+	}
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return res, false
+		}
+		res[i] = n
+	}
+
+	return res, true
+}
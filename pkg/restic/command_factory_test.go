@@ -16,11 +16,14 @@ limitations under the License.
 package restic
 
 import (
+	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBackupCommand(t *testing.T) {
@@ -32,7 +35,7 @@ func TestBackupCommand(t *testing.T) {
 	assert.Equal(t, "path", c.Dir)
 	assert.Equal(t, []string{"."}, c.Args)
 
-	expected := []string{"--tag=foo=bar", "--tag=c=d", "--hostname=velero"}
+	expected := []string{"--tag=foo=bar", "--tag=c=d", "--hostname=velero", "--json"}
 	sort.Strings(expected)
 	sort.Strings(c.ExtraFlags)
 	assert.Equal(t, expected, c.ExtraFlags)
@@ -58,7 +61,7 @@ func TestGetSnapshotCommand(t *testing.T) {
 	assert.Equal(t, "password-file", c.PasswordFile)
 
 	// set up expected flag names
-	expectedFlags := []string{"--json", "--last", "--tag"}
+	expectedFlags := []string{"--json", "--last", "--tag", "--host"}
 	// for tracking actual flag names
 	actualFlags := []string{}
 	// for tracking actual --tag values as a map
@@ -82,6 +85,10 @@ func TestGetSnapshotCommand(t *testing.T) {
 				actualTags[kvs[0]] = kvs[1]
 			}
 		}
+		// --host should always be the generic snapshot host
+		if parts[0] == "--host" {
+			assert.Equal(t, "velero", parts[1])
+		}
 	}
 
 	assert.Equal(t, expectedFlags, actualFlags)
@@ -97,12 +104,116 @@ func TestInitCommand(t *testing.T) {
 }
 
 func TestCheckCommand(t *testing.T) {
-	c := CheckCommand("repo-id")
+	c := CheckCommand("repo-id", 0, "0.12.0")
 
 	assert.Equal(t, "check", c.Command)
 	assert.Equal(t, "repo-id", c.RepoIdentifier)
 }
 
+func TestCheckCommandWithReadDataPercent(t *testing.T) {
+	tests := []struct {
+		name            string
+		readDataPercent int
+		resticVersion   string
+		expected        string
+	}{
+		{
+			name:          "zero percent has no read data flag",
+			resticVersion: "0.12.0",
+			expected:      "restic check --repo=repo-id --password-file=password-file",
+		},
+		{
+			name:            "one percent reads a subset",
+			readDataPercent: 1,
+			resticVersion:   "0.12.0",
+			expected:        "restic check --repo=repo-id --password-file=password-file --read-data-subset=1%",
+		},
+		{
+			name:            "five percent reads a subset",
+			readDataPercent: 5,
+			resticVersion:   "0.12.0",
+			expected:        "restic check --repo=repo-id --password-file=password-file --read-data-subset=5%",
+		},
+		{
+			name:            "ninety-nine percent reads a subset",
+			readDataPercent: 99,
+			resticVersion:   "0.12.0",
+			expected:        "restic check --repo=repo-id --password-file=password-file --read-data-subset=99%",
+		},
+		{
+			name:            "one hundred percent reads all data",
+			readDataPercent: 100,
+			resticVersion:   "0.12.0",
+			expected:        "restic check --repo=repo-id --password-file=password-file --read-data",
+		},
+		{
+			name:            "one hundred percent reads all data with an older restic",
+			readDataPercent: 100,
+			resticVersion:   "0.9.5",
+			expected:        "restic check --repo=repo-id --password-file=password-file --read-data",
+		},
+		{
+			name:            "ninety-nine percent reads all data with an older restic",
+			readDataPercent: 99,
+			resticVersion:   "0.9.5",
+			expected:        "restic check --repo=repo-id --password-file=password-file --read-data",
+		},
+	}
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := CheckCommand("repo-id", test.readDataPercent, test.resticVersion)
+			c.PasswordFile = "password-file"
+
+			assert.Equal(t, test.expected, c.String())
+		})
+	}
+}
+
+func TestCheckCommandWithReadDataPercentOlderRestic(t *testing.T) {
+	tests := []struct {
+		readDataPercent int
+		expectedSubsets int
+	}{
+		{readDataPercent: 1, expectedSubsets: 100},
+		{readDataPercent: 5, expectedSubsets: 20},
+		{readDataPercent: 30, expectedSubsets: 3},
+		{readDataPercent: 50, expectedSubsets: 2},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d percent", test.readDataPercent), func(t *testing.T) {
+			c := CheckCommand("repo-id", test.readDataPercent, "0.9.5")
+
+			require.Len(t, c.ExtraFlags, 1)
+
+			var subset, subsets int
+			_, err := fmt.Sscanf(c.ExtraFlags[0], "--read-data-subset=%d/%d", &subset, &subsets)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSubsets, subsets)
+			assert.True(t, subset >= 1 && subset <= subsets, "subset %d of %d", subset, subsets)
+		})
+	}
+}
+
+func TestValidateReadDataPercent(t *testing.T) {
+	assert.NoError(t, ValidateReadDataPercent(0))
+	assert.NoError(t, ValidateReadDataPercent(5))
+	assert.NoError(t, ValidateReadDataPercent(100))
+	assert.Error(t, ValidateReadDataPercent(-1))
+	assert.Error(t, ValidateReadDataPercent(101))
+}
+
+func TestListSnapshotsCommand(t *testing.T) {
+	c := ListSnapshotsCommand("repo-id")
+
+	assert.Equal(t, "snapshots", c.Command)
+	assert.Equal(t, "repo-id", c.RepoIdentifier)
+	assert.Equal(t, []string{"--json", "--last"}, c.ExtraFlags)
+}
+
 func TestPruneCommand(t *testing.T) {
 	c := PruneCommand("repo-id")
 
@@ -117,3 +228,208 @@ func TestForgetCommand(t *testing.T) {
 	assert.Equal(t, "repo-id", c.RepoIdentifier)
 	assert.Equal(t, []string{"snapshot-id"}, c.Args)
 }
+
+func TestAddKeyCommand(t *testing.T) {
+	c := AddKeyCommand("repo-id", "new-password-file")
+
+	assert.Equal(t, "key", c.Command)
+	assert.Equal(t, "repo-id", c.RepoIdentifier)
+	assert.Equal(t, []string{"add"}, c.Args)
+	assert.Equal(t, []string{"--new-password-file=new-password-file"}, c.ExtraFlags)
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+	c.PasswordFile = "password-file"
+	assert.Equal(t, "restic key --repo=repo-id --password-file=password-file add --new-password-file=new-password-file", c.String())
+}
+
+func TestRemoveKeyCommand(t *testing.T) {
+	c := RemoveKeyCommand("repo-id", "key-id")
+
+	assert.Equal(t, "key", c.Command)
+	assert.Equal(t, "repo-id", c.RepoIdentifier)
+	assert.Equal(t, []string{"remove", "key-id"}, c.Args)
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+	c.PasswordFile = "password-file"
+	assert.Equal(t, "restic key --repo=repo-id --password-file=password-file remove key-id", c.String())
+}
+
+func TestVersionCommand(t *testing.T) {
+	c := VersionCommand()
+
+	assert.Equal(t, "version", c.Command)
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+	assert.Equal(t, "restic version", c.String())
+}
+
+func TestValidatePackSize(t *testing.T) {
+	assert.Error(t, ValidatePackSize(3))
+	assert.NoError(t, ValidatePackSize(4))
+	assert.NoError(t, ValidatePackSize(64))
+	assert.NoError(t, ValidatePackSize(128))
+	assert.Error(t, ValidatePackSize(129))
+}
+
+func TestBackupCommandWithPackSizeFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		packSize      int
+		resticVersion string
+		expected      string
+	}{
+		{
+			name:          "supported restic version has the flag appended",
+			packSize:      64,
+			resticVersion: "0.14.0",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json --pack-size=64",
+		},
+		{
+			name:          "newer restic version with a suffix has the flag appended",
+			packSize:      64,
+			resticVersion: "0.15.1-dev",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json --pack-size=64",
+		},
+		{
+			name:          "unsupported restic version has the flag omitted",
+			packSize:      64,
+			resticVersion: "0.9.5",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json",
+		},
+		{
+			name:          "unparseable restic version has the flag omitted",
+			packSize:      64,
+			resticVersion: "unknown",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json",
+		},
+		{
+			name:          "zero pack size has the flag omitted",
+			resticVersion: "0.14.0",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json",
+		},
+	}
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := BackupCommand("repo-id", "password-file", "path", nil)
+			c.ExtraFlags = append(c.ExtraFlags, PackSizeFlags(test.packSize, test.resticVersion)...)
+
+			assert.Equal(t, test.expected, c.String())
+		})
+	}
+}
+
+func TestBackupCommandWithOneFileSystemFlags(t *testing.T) {
+	c := BackupCommand("repo-id", "password-file", "path", nil)
+	c.ExtraFlags = append(c.ExtraFlags, OneFileSystemFlags(true)...)
+	assert.Equal(t, "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json --one-file-system", c.String())
+
+	c = BackupCommand("repo-id", "password-file", "path", nil)
+	c.ExtraFlags = append(c.ExtraFlags, OneFileSystemFlags(false)...)
+	assert.Equal(t, "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json", c.String())
+}
+
+func TestValidateCompression(t *testing.T) {
+	assert.NoError(t, ValidateCompression("auto"))
+	assert.NoError(t, ValidateCompression("off"))
+	assert.NoError(t, ValidateCompression("max"))
+	assert.Error(t, ValidateCompression(""))
+	assert.Error(t, ValidateCompression("min"))
+}
+
+func TestBackupCommandWithCompressionFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		resticVersion string
+		expected      string
+	}{
+		{
+			name:          "auto mode has the flag appended",
+			mode:          "auto",
+			resticVersion: "0.14.0",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json --compression=auto",
+		},
+		{
+			name:          "off mode has the flag appended",
+			mode:          "off",
+			resticVersion: "0.14.0",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json --compression=off",
+		},
+		{
+			name:          "max mode has the flag appended",
+			mode:          "max",
+			resticVersion: "0.16.4",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json --compression=max",
+		},
+		{
+			name:          "unsupported restic version has the flag omitted",
+			mode:          "max",
+			resticVersion: "0.9.5",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json",
+		},
+		{
+			name:          "empty mode has the flag omitted",
+			resticVersion: "0.14.0",
+			expected:      "restic backup --repo=repo-id --password-file=password-file . --hostname=velero --json",
+		},
+	}
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := BackupCommand("repo-id", "password-file", "path", nil)
+			c.ExtraFlags = append(c.ExtraFlags, CompressionFlags(test.mode, test.resticVersion)...)
+
+			assert.Equal(t, test.expected, c.String())
+		})
+	}
+}
+
+func TestInitCommandWithRepositoryFlags(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		resticVersion string
+		expected      string
+	}{
+		{
+			name:          "auto mode creates a repository that supports compression",
+			mode:          "auto",
+			resticVersion: "0.14.0",
+			expected:      "restic init --repo=repo-id --repository-version=2",
+		},
+		{
+			name:          "max mode creates a repository that supports compression",
+			mode:          "max",
+			resticVersion: "0.14.0",
+			expected:      "restic init --repo=repo-id --repository-version=2",
+		},
+		{
+			name:          "off mode uses restic's default repository version",
+			mode:          "off",
+			resticVersion: "0.14.0",
+			expected:      "restic init --repo=repo-id",
+		},
+		{
+			name:          "unsupported restic version uses restic's default repository version",
+			mode:          "auto",
+			resticVersion: "0.9.5",
+			expected:      "restic init --repo=repo-id",
+		},
+	}
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := InitCommand("repo-id")
+			c.ExtraFlags = append(c.ExtraFlags, InitRepositoryFlags(test.mode, test.resticVersion)...)
+
+			assert.Equal(t, test.expected, c.String())
+		})
+	}
+}
@@ -104,3 +104,54 @@ func TestCmd(t *testing.T) {
 	assert.Equal(t, c.StringSlice(), execCmd.Args)
 	assert.Equal(t, c.Dir, execCmd.Dir)
 }
+
+func TestCmdPassesShellMetacharactersLiterally(t *testing.T) {
+	c := BackupCommand(
+		"s3:s3.amazonaws.com/bucket/restic/ns-1; rm -rf /",
+		"/tmp/$(whoami)",
+		"/host_pods/pod-uid/volumes/kubernetes.io~empty-dir/vol`id`",
+		map[string]string{"volume": "vol && reboot"},
+	)
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+	execCmd := c.Cmd()
+
+	// restic is run directly rather than through a shell, so each value is
+	// a single argument and metacharacters in it aren't interpreted.
+	assert.Equal(t, "restic", execCmd.Args[0])
+	assert.Contains(t, execCmd.Args, "--repo=s3:s3.amazonaws.com/bucket/restic/ns-1; rm -rf /")
+	assert.Contains(t, execCmd.Args, "--password-file=/tmp/$(whoami)")
+	assert.Contains(t, execCmd.Args, "--tag=volume=vol && reboot")
+	assert.Equal(t, "/host_pods/pod-uid/volumes/kubernetes.io~empty-dir/vol`id`", execCmd.Dir)
+}
+
+func TestCmdEnv(t *testing.T) {
+	c := &Command{
+		Command:        "cmd",
+		RepoIdentifier: "repo-id",
+		PasswordFile:   "/path/to/password-file",
+	}
+
+	// with no env specified, the command inherits the current
+	// process's environment.
+	assert.Nil(t, c.Cmd().Env)
+
+	c.Env = []string{"AZURE_ACCOUNT_NAME=foo", "AZURE_ACCOUNT_KEY=bar"}
+	assert.Equal(t, c.Env, c.Cmd().Env)
+}
+
+func TestStringDoesNotIncludeSecrets(t *testing.T) {
+	c := &Command{
+		Command:        "cmd",
+		RepoIdentifier: "repo-id",
+		PasswordFile:   "/path/to/password-file",
+		Env:            []string{"AZURE_ACCOUNT_KEY=secret-key"},
+	}
+
+	require.NoError(t, os.Unsetenv("VELERO_SCRATCH_DIR"))
+
+	// the password is only referenced by its file path, and env
+	// values, which can hold storage credentials, aren't included.
+	assert.Equal(t, "restic cmd --repo=repo-id --password-file=/path/to/password-file", c.String())
+	assert.NotContains(t, c.String(), "secret-key")
+}
@@ -19,10 +19,14 @@ package restic
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	corev1listers "k8s.io/client-go/listers/core/v1"
@@ -37,6 +41,7 @@ const (
 	DaemonSet                   = "restic"
 	InitContainer               = "restic-wait"
 	DefaultMaintenanceFrequency = 24 * time.Hour
+	DefaultPVCBindTimeout       = 5 * time.Minute
 
 	podAnnotationPrefix       = "snapshot.velero.io/"
 	volumesToBackupAnnotation = "backup.velero.io/backup-volumes"
@@ -46,6 +51,38 @@ const (
 	volumesToBackupLegacyAnnotation = "backup.ark.heptio.com/backup-volumes"
 )
 
+// MaxVolumeSizeAnnotation is the annotation on a backup that sets the
+// largest size, as a resource quantity, of a volume that can be backed up
+// with restic. It overrides the restic server's default.
+const MaxVolumeSizeAnnotation = "velero.io/restic-max-volume-size"
+
+// OneFileSystemAnnotation is the annotation on a backup that, when set to
+// "true" or "false", overrides the restic server's default for whether
+// restic backups stay on the filesystem of the volume being backed up.
+const OneFileSystemAnnotation = "velero.io/restic-one-file-system"
+
+// FailFastAnnotation is the annotation on a backup that, when set to "true",
+// makes BackupPodVolumes stop waiting for a pod's volume backups and return as
+// soon as one of them fails, rather than waiting for all of them to finish.
+const FailFastAnnotation = "velero.io/restic-fail-fast"
+
+// BackupNamespaceAnnotation is the annotation on a namespace that, when set
+// to "disabled", turns off restic backups of the volumes of all pods in the
+// namespace, regardless of the pods' backup-volumes annotations.
+const BackupNamespaceAnnotation = "velero.io/restic-backup"
+
+// RestoreVolumesAnnotation is the annotation on a restore that limits the
+// pod volumes restored with restic to the ones it lists. Its value is a
+// comma-separated list of volumes, each either a volume name, which matches
+// that volume in any pod, or <namespace>/<pod name>/<volume name>, where the
+// namespace is the pod's namespace in the cluster being restored into.
+const RestoreVolumesAnnotation = "velero.io/restic-restore-volumes"
+
+// SkipRestoreVolumesAnnotation is the annotation on a restore that lists,
+// in the same form as RestoreVolumesAnnotation, pod volumes that aren't
+// restored with restic. It takes precedence over RestoreVolumesAnnotation.
+const SkipRestoreVolumesAnnotation = "velero.io/restic-skip-restore-volumes"
+
 // PodHasSnapshotAnnotation returns true if the object has an annotation
 // indicating that there is a restic snapshot for a volume in this pod,
 // or false otherwise.
@@ -95,6 +132,46 @@ func GetPodSnapshotAnnotations(obj metav1.Object) map[string]string {
 	return res
 }
 
+// GetVolumesToRestore returns a map, of volume name -> snapshot id, of the
+// restic snapshots for this pod that the restore's RestoreVolumesAnnotation
+// and SkipRestoreVolumesAnnotation select for restoring, and the sorted names
+// of the pod's volumes with snapshots that they exclude. Qualified entries in
+// the annotations are matched against namespace, the namespace that the pod
+// is restored into.
+func GetVolumesToRestore(restore *velerov1api.Restore, pod metav1.Object, namespace string) (map[string]string, []string) {
+	volumeSnapshots := GetPodSnapshotAnnotations(pod)
+
+	included, includedSet := restore.Annotations[RestoreVolumesAnnotation]
+	excluded := restore.Annotations[SkipRestoreVolumesAnnotation]
+
+	var skipped []string
+	for volume := range volumeSnapshots {
+		if (includedSet && !volumeListContains(included, namespace, pod.GetName(), volume)) || volumeListContains(excluded, namespace, pod.GetName(), volume) {
+			skipped = append(skipped, volume)
+			delete(volumeSnapshots, volume)
+		}
+	}
+	sort.Strings(skipped)
+
+	return volumeSnapshots, skipped
+}
+
+// volumeListContains returns true if the comma-separated list of volumes, in
+// the form of RestoreVolumesAnnotation's value, includes the volume of the
+// pod named podName in namespace.
+func volumeListContains(list, namespace, podName, volume string) bool {
+	qualified := fmt.Sprintf("%s/%s/%s", namespace, podName, volume)
+
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == volume || item == qualified {
+			return true
+		}
+	}
+
+	return false
+}
+
 // SetPodSnapshotAnnotation adds an annotation to a pod to indicate that
 // the specified volume has a restic snapshot with the provided id.
 func SetPodSnapshotAnnotation(obj metav1.Object, volumeName, snapshotID string) {
@@ -188,12 +265,18 @@ func TempCredentialsFile(secretLister corev1listers.SecretLister, veleroNamespac
 		return "", err
 	}
 
-	file, err := fs.TempFile("", fmt.Sprintf("%s-%s", CredentialsSecretName, repoName))
+	return tempPasswordFile(fs, fmt.Sprintf("%s-%s", CredentialsSecretName, repoName), repoKey)
+}
+
+// tempPasswordFile creates a temp file with the specified name prefix
+// containing password, and returns its path.
+func tempPasswordFile(fs filesystem.Interface, prefix string, password []byte) (string, error) {
+	file, err := fs.TempFile("", prefix)
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
 
-	if _, err := file.Write(repoKey); err != nil {
+	if _, err := file.Write(password); err != nil {
 		// nothing we can do about an error closing the file here, and we're
 		// already returning an error about the write failing.
 		file.Close()
@@ -209,6 +292,114 @@ func TempCredentialsFile(secretLister corev1listers.SecretLister, veleroNamespac
 	return name, nil
 }
 
+// GetMaxVolumeSize returns the largest size, in bytes, of a volume that can
+// be backed up for the PodVolumeBackup: the value of its MaxVolumeSizeAnnotation
+// if it has one, or defaultSize otherwise. Zero means there is no limit.
+func GetMaxVolumeSize(pvb *velerov1api.PodVolumeBackup, defaultSize int64) (int64, error) {
+	val, ok := pvb.Annotations[MaxVolumeSizeAnnotation]
+	if !ok {
+		return defaultSize, nil
+	}
+
+	size, err := resource.ParseQuantity(val)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error parsing %s annotation value %q", MaxVolumeSizeAnnotation, val)
+	}
+
+	return size.Value(), nil
+}
+
+// GetOneFileSystem returns whether the PodVolumeBackup's restic backup should
+// stay on the volume's filesystem: the value of its OneFileSystemAnnotation if
+// it has one, or defaultValue otherwise.
+func GetOneFileSystem(pvb *velerov1api.PodVolumeBackup, defaultValue bool) (bool, error) {
+	val, ok := pvb.Annotations[OneFileSystemAnnotation]
+	if !ok {
+		return defaultValue, nil
+	}
+
+	oneFileSystem, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, errors.Wrapf(err, "error parsing %s annotation value %q", OneFileSystemAnnotation, val)
+	}
+
+	return oneFileSystem, nil
+}
+
+// EnsureVolumeSizeWithinLimit returns an error if the total size of the
+// regular files under dir is greater than maxSize bytes. It stops adding up
+// their sizes as soon as the total is over maxSize. If oneFileSystem is true,
+// directories on a different device from dir, i.e. other filesystems mounted
+// inside it, aren't counted, since restic doesn't back them up.
+func EnsureVolumeSizeWithinLimit(fs filesystem.Interface, dir string, maxSize int64, oneFileSystem bool) error {
+	includeDir := func(os.FileInfo) bool { return true }
+
+	if oneFileSystem {
+		info, err := fs.Stat(dir)
+		if err != nil {
+			return errors.Wrapf(err, "error getting info for directory %s", dir)
+		}
+
+		// if dir's device isn't known, no directories can be skipped.
+		if device, ok := getFileDevice(info); ok {
+			includeDir = func(info os.FileInfo) bool {
+				dirDevice, ok := getFileDevice(info)
+				return !ok || dirDevice == device
+			}
+		}
+	}
+
+	size, err := getDirSize(fs, dir, maxSize, includeDir)
+	if err != nil {
+		return err
+	}
+
+	if size > maxSize {
+		return errors.Errorf("volume size exceeds configured max volume size of %d bytes", maxSize)
+	}
+
+	return nil
+}
+
+// getFileDevice returns the ID of the device that a file is on, and whether
+// it's known. It's a variable so that tests can replace it.
+var getFileDevice = fileDevice
+
+// getDirSize returns the total size of the regular files under dir, or a
+// partial total as soon as it's greater than limit. Symlinks aren't followed,
+// matching restic, and subdirectories for which includeDir returns false
+// aren't counted.
+func getDirSize(fs filesystem.Interface, dir string, limit int64, includeDir func(os.FileInfo) bool) (int64, error) {
+	entries, err := fs.ReadDir(dir)
+	if err != nil {
+		return 0, errors.Wrapf(err, "error reading directory %s", dir)
+	}
+
+	var size int64
+	for _, entry := range entries {
+		switch {
+		case entry.IsDir():
+			if !includeDir(entry) {
+				continue
+			}
+
+			dirSize, err := getDirSize(fs, filepath.Join(dir, entry.Name()), limit-size, includeDir)
+			if err != nil {
+				return 0, err
+			}
+			size += dirSize
+		case entry.Mode().IsRegular():
+			size += entry.Size()
+		}
+
+		if size > limit {
+			return size, nil
+		}
+	}
+
+	return size, nil
+}
+
 // NewPodVolumeBackupListOptions creates a ListOptions with a label selector configured to
 // find PodVolumeBackups for the backup identified by name.
 func NewPodVolumeBackupListOptions(name string) metav1.ListOptions {
@@ -17,6 +17,7 @@ limitations under the License.
 package restic
 
 import (
+	"os"
 	"sort"
 	"testing"
 
@@ -247,6 +248,88 @@ func TestGetVolumesToBackup(t *testing.T) {
 	}
 }
 
+func TestGetVolumesToRestore(t *testing.T) {
+	tests := []struct {
+		name               string
+		restoreAnnotations map[string]string
+		namespace          string
+		expected           map[string]string
+		expectedSkipped    []string
+	}{
+		{
+			name:     "no restore annotations restores all volumes",
+			expected: map[string]string{"vol-1": "snap-1", "vol-2": "snap-2", "vol-3": "snap-3"},
+		},
+		{
+			name:               "only included volumes are restored",
+			restoreAnnotations: map[string]string{RestoreVolumesAnnotation: "vol-2, vol-3"},
+			expected:           map[string]string{"vol-2": "snap-2", "vol-3": "snap-3"},
+			expectedSkipped:    []string{"vol-1"},
+		},
+		{
+			name:               "included volumes can be qualified with the pod's namespace and name",
+			restoreAnnotations: map[string]string{RestoreVolumesAnnotation: "ns-1/pod-1/vol-1,ns-1/other-pod/vol-2"},
+			expected:           map[string]string{"vol-1": "snap-1"},
+			expectedSkipped:    []string{"vol-2", "vol-3"},
+		},
+		{
+			name:               "qualified volumes are matched against the namespace the pod is restored into",
+			restoreAnnotations: map[string]string{SkipRestoreVolumesAnnotation: "ns-1/pod-1/vol-1,ns-2/pod-1/vol-2"},
+			namespace:          "ns-2",
+			expected:           map[string]string{"vol-1": "snap-1", "vol-3": "snap-3"},
+			expectedSkipped:    []string{"vol-2"},
+		},
+		{
+			name:               "excluded volumes aren't restored",
+			restoreAnnotations: map[string]string{SkipRestoreVolumesAnnotation: "vol-1"},
+			expected:           map[string]string{"vol-2": "snap-2", "vol-3": "snap-3"},
+			expectedSkipped:    []string{"vol-1"},
+		},
+		{
+			name: "exclusions take precedence over inclusions",
+			restoreAnnotations: map[string]string{
+				RestoreVolumesAnnotation:     "vol-1,vol-2",
+				SkipRestoreVolumesAnnotation: "ns-1/pod-1/vol-2",
+			},
+			expected:        map[string]string{"vol-1": "snap-1"},
+			expectedSkipped: []string{"vol-2", "vol-3"},
+		},
+		{
+			name:               "empty inclusion list restores no volumes",
+			restoreAnnotations: map[string]string{RestoreVolumesAnnotation: ""},
+			expected:           map[string]string{},
+			expectedSkipped:    []string{"vol-1", "vol-2", "vol-3"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1api.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns-1",
+					Name:      "pod-1",
+					Annotations: map[string]string{
+						podAnnotationPrefix + "vol-1": "snap-1",
+						podAnnotationPrefix + "vol-2": "snap-2",
+						podAnnotationPrefix + "vol-3": "snap-3",
+					},
+				},
+			}
+			restore := &velerov1api.Restore{ObjectMeta: metav1.ObjectMeta{Annotations: test.restoreAnnotations}}
+
+			namespace := test.namespace
+			if namespace == "" {
+				namespace = pod.Namespace
+			}
+
+			res, skipped := GetVolumesToRestore(restore, pod, namespace)
+
+			assert.Equal(t, test.expected, res)
+			assert.Equal(t, test.expectedSkipped, skipped)
+		})
+	}
+}
+
 func TestGetSnapshotsInBackup(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -399,3 +482,135 @@ func TestTempCredentialsFile(t *testing.T) {
 
 	assert.Equal(t, "passw0rd", string(contents))
 }
+
+func TestGetMaxVolumeSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expected    int64
+		expectedErr bool
+	}{
+		{
+			name:     "no annotation returns the default",
+			expected: 1000,
+		},
+		{
+			name:        "annotation overrides the default",
+			annotations: map[string]string{MaxVolumeSizeAnnotation: "1Ki"},
+			expected:    1024,
+		},
+		{
+			name:        "annotation of zero removes the limit",
+			annotations: map[string]string{MaxVolumeSizeAnnotation: "0"},
+			expected:    0,
+		},
+		{
+			name:        "invalid annotation returns an error",
+			annotations: map[string]string{MaxVolumeSizeAnnotation: "foo"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pvb := &velerov1api.PodVolumeBackup{
+				ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations},
+			}
+
+			res, err := GetMaxVolumeSize(pvb, 1000)
+
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestGetOneFileSystem(t *testing.T) {
+	tests := []struct {
+		name         string
+		annotations  map[string]string
+		defaultValue bool
+		expected     bool
+		expectedErr  bool
+	}{
+		{
+			name:         "no annotation returns the default",
+			defaultValue: true,
+			expected:     true,
+		},
+		{
+			name:         "annotation of true overrides the default",
+			annotations:  map[string]string{OneFileSystemAnnotation: "true"},
+			defaultValue: false,
+			expected:     true,
+		},
+		{
+			name:         "annotation of false overrides the default",
+			annotations:  map[string]string{OneFileSystemAnnotation: "false"},
+			defaultValue: true,
+			expected:     false,
+		},
+		{
+			name:        "invalid annotation returns an error",
+			annotations: map[string]string{OneFileSystemAnnotation: "foo"},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pvb := &velerov1api.PodVolumeBackup{
+				ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations},
+			}
+
+			res, err := GetOneFileSystem(pvb, test.defaultValue)
+
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestEnsureVolumeSizeWithinLimit(t *testing.T) {
+	fs := velerotest.NewFakeFileSystem().
+		WithFile("/volume/file-1", make([]byte, 100)).
+		WithFile("/volume/dir-1/file-2", make([]byte, 50)).
+		WithFile("/volume/dir-1/dir-2/file-3", make([]byte, 25)).
+		WithDirectory("/volume/empty-dir")
+
+	assert.NoError(t, EnsureVolumeSizeWithinLimit(fs, "/volume", 175, false))
+	assert.Error(t, EnsureVolumeSizeWithinLimit(fs, "/volume", 174, false))
+	assert.Error(t, EnsureVolumeSizeWithinLimit(fs, "/nonexistent", 1000, false))
+}
+
+func TestEnsureVolumeSizeWithinLimitStopsAtLimit(t *testing.T) {
+	fs := velerotest.NewFakeFileSystem().
+		WithFile("/volume/dir-1/file-1", make([]byte, 100)).
+		WithFile("/volume/dir-1/file-2", make([]byte, 50)).
+		WithFile("/volume/dir-2/file-3", make([]byte, 25))
+
+	assert.Error(t, EnsureVolumeSizeWithinLimit(fs, "/volume", 99, false))
+
+	// the total is over the limit after dir-1, so dir-2 isn't read.
+	assert.Equal(t, []string{"/volume", "/volume/dir-1"}, fs.ReadDirCalls)
+}
+
+func TestEnsureVolumeSizeWithinLimitOneFileSystem(t *testing.T) {
+	// the fake filesystem doesn't have devices, so they're looked up by
+	// name: mount is on a different device from everything else.
+	getFileDevice = func(info os.FileInfo) (uint64, bool) {
+		if info.Name() == "mount" {
+			return 2, true
+		}
+		return 1, true
+	}
+	defer func() { getFileDevice = fileDevice }()
+
+	fs := velerotest.NewFakeFileSystem().
+		WithFile("/volume/file-1", make([]byte, 100)).
+		WithFile("/volume/dir-1/file-2", make([]byte, 50)).
+		WithFile("/volume/mount/file-3", make([]byte, 1000))
+
+	assert.NoError(t, EnsureVolumeSizeWithinLimit(fs, "/volume", 150, true))
+	assert.Error(t, EnsureVolumeSizeWithinLimit(fs, "/volume", 150, false))
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	// VolumeBackupFailedReason is the reason of the event recorded on a
+	// backup for each pod volume that failed to back up with restic.
+	VolumeBackupFailedReason = "PodVolumeBackupFailed"
+
+	// VolumeRestoreFailedReason is the reason of the event recorded on a
+	// restore for each pod volume that failed to restore with restic.
+	VolumeRestoreFailedReason = "PodVolumeRestoreFailed"
+)
+
+// EventRecorder records Kubernetes events about Velero API objects.
+type EventRecorder interface {
+	// Event records an event of type eventType, i.e. Normal or Warning, on
+	// the object that ref refers to.
+	Event(ref *corev1api.ObjectReference, eventType, reason, message string)
+}
+
+type eventRecorder struct {
+	client    corev1client.EventsGetter
+	component string
+	log       logrus.FieldLogger
+}
+
+// NewEventRecorder returns an EventRecorder that creates events with client,
+// with component as their source. Events that can't be created are logged
+// and dropped, so that they never fail a backup or restore.
+func NewEventRecorder(client corev1client.EventsGetter, component string, log logrus.FieldLogger) EventRecorder {
+	return &eventRecorder{
+		client:    client,
+		component: component,
+		log:       log,
+	}
+}
+
+func (r *eventRecorder) Event(ref *corev1api.ObjectReference, eventType, reason, message string) {
+	now := metav1.Now()
+
+	event := &corev1api.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: ref.Namespace,
+			// this is how client-go's event recorder names events, so that
+			// they're unique.
+			Name: fmt.Sprintf("%v.%x", ref.Name, now.UnixNano()),
+		},
+		InvolvedObject: *ref,
+		Reason:         reason,
+		Message:        message,
+		Type:           eventType,
+		Source:         corev1api.EventSource{Component: r.component},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+
+	if _, err := r.client.Events(ref.Namespace).Create(event); err != nil {
+		r.log.WithError(err).Warnf("Error recording %s event on %s %s/%s", reason, ref.Kind, ref.Namespace, ref.Name)
+	}
+}
+
+// objectReference returns a reference to the Velero API object of kind.
+func objectReference(kind string, obj metav1.Object) *corev1api.ObjectReference {
+	return &corev1api.ObjectReference{
+		APIVersion: velerov1api.SchemeGroupVersion.String(),
+		Kind:       kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+		UID:        obj.GetUID(),
+	}
+}
+
+// recordVolumeFailures records a warning event on the object that ref refers
+// to for each of the pod's volumes in volumes that failed to back up or
+// restore, depending on reason.
+func recordVolumeFailures(recorder EventRecorder, ref *corev1api.ObjectReference, reason string, pod *corev1api.Pod, volumes []VolumeResult) {
+	operation := "back up"
+	if reason == VolumeRestoreFailedReason {
+		operation = "restore"
+	}
+
+	for _, volume := range volumes {
+		if volume.Status != VolumeResultFailed {
+			continue
+		}
+
+		recorder.Event(ref, corev1api.EventTypeWarning, reason, fmt.Sprintf("Failed to %s volume %s of pod %s/%s with restic: %s", operation, volume.Volume, pod.Namespace, pod.Name, volume.Message))
+	}
+}
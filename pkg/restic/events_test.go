@@ -0,0 +1,121 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+// recordedEvent is an event recorded by fakeEventRecorder.
+type recordedEvent struct {
+	ref       *corev1api.ObjectReference
+	eventType string
+	reason    string
+	message   string
+}
+
+// fakeEventRecorder is an EventRecorder that keeps the events it records.
+type fakeEventRecorder struct {
+	events []recordedEvent
+}
+
+func (r *fakeEventRecorder) Event(ref *corev1api.ObjectReference, eventType, reason, message string) {
+	r.events = append(r.events, recordedEvent{ref: ref, eventType: eventType, reason: reason, message: message})
+}
+
+// fakeEventClient is an EventsGetter whose Create keeps the events it's
+// passed, or returns err if it's set.
+type fakeEventClient struct {
+	corev1client.EventInterface
+
+	events []*corev1api.Event
+	err    error
+}
+
+func (c *fakeEventClient) Events(namespace string) corev1client.EventInterface {
+	return c
+}
+
+func (c *fakeEventClient) Create(event *corev1api.Event) (*corev1api.Event, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.events = append(c.events, event)
+	return event, nil
+}
+
+func TestEventRecorderEvent(t *testing.T) {
+	client := &fakeEventClient{}
+	recorder := NewEventRecorder(client, "velero", velerotest.NewLogger())
+
+	backup := velerotest.NewTestBackup().WithNamespace("velero").WithName("backup-1").Backup
+	backup.UID = "uid-1"
+
+	recorder.Event(objectReference("Backup", backup), corev1api.EventTypeWarning, VolumeBackupFailedReason, "volume failed")
+
+	require.Len(t, client.events, 1)
+	event := client.events[0]
+	assert.Equal(t, "velero", event.Namespace)
+	assert.Equal(t, corev1api.ObjectReference{
+		APIVersion: "velero.io/v1",
+		Kind:       "Backup",
+		Namespace:  "velero",
+		Name:       "backup-1",
+		UID:        "uid-1",
+	}, event.InvolvedObject)
+	assert.Equal(t, corev1api.EventTypeWarning, event.Type)
+	assert.Equal(t, VolumeBackupFailedReason, event.Reason)
+	assert.Equal(t, "volume failed", event.Message)
+	assert.Equal(t, "velero", event.Source.Component)
+	assert.Equal(t, int32(1), event.Count)
+
+	// errors creating events are logged, not returned or panicked on.
+	client.err = errors.New("create error")
+	recorder.Event(objectReference("Backup", backup), corev1api.EventTypeWarning, VolumeBackupFailedReason, "volume failed")
+	assert.Len(t, client.events, 1)
+}
+
+func TestRecordVolumeFailures(t *testing.T) {
+	recorder := &fakeEventRecorder{}
+	restore := velerotest.NewTestRestore("velero", "restore-1", "").Restore
+	ref := objectReference("Restore", restore)
+	pod := &corev1api.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "pod-1"}}
+
+	recordVolumeFailures(recorder, ref, VolumeRestoreFailedReason, pod, []VolumeResult{
+		{Volume: "vol-1", Status: VolumeResultCompleted, SnapshotID: "snapshot-1"},
+		{Volume: "vol-2", Status: VolumeResultFailed, Message: "restic error"},
+		{Volume: "vol-3", Status: VolumeResultSkipped, Message: "excluded by the restore's annotations"},
+	})
+
+	assert.Equal(t, []recordedEvent{
+		{
+			ref:       ref,
+			eventType: corev1api.EventTypeWarning,
+			reason:    VolumeRestoreFailedReason,
+			message:   "Failed to restore volume vol-2 of pod ns-1/pod-1 with restic: restic error",
+		},
+	}, recorder.events)
+}
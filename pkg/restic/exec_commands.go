@@ -18,6 +18,7 @@ package restic
 
 import (
 	"encoding/json"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -53,3 +54,59 @@ func GetSnapshotID(repoIdentifier, passwordFile string, tags map[string]string,
 
 	return snapshots[0].ShortID, nil
 }
+
+// GetVersion runs a 'restic version' command and returns the version of
+// restic, e.g. "0.9.5".
+func GetVersion() (string, error) {
+	cmd := VersionCommand()
+
+	stdout, stderr, err := exec.RunCommand(cmd.Cmd())
+	if err != nil {
+		return "", errors.Wrapf(err, "error running command, stderr=%s", stderr)
+	}
+
+	return parseVersionOutput(stdout)
+}
+
+// parseVersionOutput returns the version from the output of a 'restic version'
+// command, which looks like "restic 0.9.5 compiled with go1.12.4 on linux/amd64".
+func parseVersionOutput(stdout string) (string, error) {
+	fields := strings.Fields(stdout)
+	if len(fields) < 2 || fields[0] != "restic" {
+		return "", errors.Errorf("unexpected restic version output %q", stdout)
+	}
+
+	return fields[1], nil
+}
+
+// BackupSummary contains the statistics that restic reports at the end of
+// a backup.
+type BackupSummary struct {
+	BytesAdded     int64 `json:"data_added"`
+	FilesProcessed int64 `json:"total_files_processed"`
+	BytesProcessed int64 `json:"total_bytes_processed"`
+}
+
+// GetBackupSummary returns the summary from the output of a 'restic backup
+// --json' command, or an error if the output doesn't contain one.
+func GetBackupSummary(stdout string) (*BackupSummary, error) {
+	// the output is a series of JSON messages, one per line, and the
+	// summary is the last of them.
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		var message struct {
+			MessageType string `json:"message_type"`
+			BackupSummary
+		}
+
+		if err := json.Unmarshal([]byte(lines[i]), &message); err != nil {
+			continue
+		}
+
+		if message.MessageType == "summary" {
+			return &message.BackupSummary, nil
+		}
+	}
+
+	return nil, errors.New("no summary found in restic backup output")
+}
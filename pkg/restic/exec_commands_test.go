@@ -0,0 +1,73 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBackupSummary(t *testing.T) {
+	tests := []struct {
+		name        string
+		stdout      string
+		expected    *BackupSummary
+		expectedErr bool
+	}{
+		{
+			name: "summary after status messages is returned",
+			stdout: `{"message_type":"status","percent_done":0,"total_files":1,"total_bytes":10}
+{"message_type":"status","percent_done":1,"total_files":12,"files_done":12,"total_bytes":4096,"bytes_done":4096}
+{"message_type":"summary","files_new":10,"files_changed":2,"files_unmodified":0,"dirs_new":1,"dirs_changed":0,"dirs_unmodified":0,"data_blobs":12,"tree_blobs":2,"data_added":2048,"total_files_processed":12,"total_bytes_processed":4096,"total_duration":0.5,"snapshot_id":"abc123"}
+`,
+			expected: &BackupSummary{
+				BytesAdded:     2048,
+				FilesProcessed: 12,
+				BytesProcessed: 4096,
+			},
+		},
+		{
+			name:        "output without a summary returns an error",
+			stdout:      `{"message_type":"status","percent_done":0.5}`,
+			expectedErr: true,
+		},
+		{
+			name:        "non-JSON output returns an error",
+			stdout:      "snapshot abc123 saved\n",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summary, err := GetBackupSummary(test.stdout)
+
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expected, summary)
+		})
+	}
+}
+
+func TestParseVersionOutput(t *testing.T) {
+	version, err := parseVersionOutput("restic 0.9.5 compiled with go1.12.4 on linux/amd64\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "0.9.5", version)
+
+	_, err = parseVersionOutput("unexpected output")
+	assert.Error(t, err)
+}
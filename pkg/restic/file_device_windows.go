@@ -0,0 +1,26 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import "os"
+
+// fileDevice returns the ID of the device that info's file is on, and
+// whether it's known. It's never known on Windows, where volumes aren't
+// backed up.
+func fileDevice(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
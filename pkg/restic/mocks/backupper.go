@@ -4,6 +4,8 @@ package mocks
 import corev1 "k8s.io/api/core/v1"
 import logrus "github.com/sirupsen/logrus"
 import mock "github.com/stretchr/testify/mock"
+import restic "github.com/heptio/velero/pkg/restic"
+import listersv1 "k8s.io/client-go/listers/core/v1"
 
 import v1 "github.com/heptio/velero/pkg/apis/velero/v1"
 
@@ -13,15 +15,15 @@ type Backupper struct {
 }
 
 // BackupPodVolumes provides a mock function with given fields: backup, pod, log
-func (_m *Backupper) BackupPodVolumes(backup *v1.Backup, pod *corev1.Pod, log logrus.FieldLogger) (map[string]string, []error) {
+func (_m *Backupper) BackupPodVolumes(backup *v1.Backup, pod *corev1.Pod, log logrus.FieldLogger) (*restic.PodVolumeBackupResult, []error) {
 	ret := _m.Called(backup, pod, log)
 
-	var r0 map[string]string
-	if rf, ok := ret.Get(0).(func(*v1.Backup, *corev1.Pod, logrus.FieldLogger) map[string]string); ok {
+	var r0 *restic.PodVolumeBackupResult
+	if rf, ok := ret.Get(0).(func(*v1.Backup, *corev1.Pod, logrus.FieldLogger) *restic.PodVolumeBackupResult); ok {
 		r0 = rf(backup, pod, log)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(map[string]string)
+			r0 = ret.Get(0).(*restic.PodVolumeBackupResult)
 		}
 	}
 
@@ -36,3 +38,26 @@ func (_m *Backupper) BackupPodVolumes(backup *v1.Backup, pod *corev1.Pod, log lo
 
 	return r0, r1
 }
+
+// PlanPodVolumeBackup provides a mock function with given fields: pod, pvcLister, log
+func (_m *Backupper) PlanPodVolumeBackup(pod *corev1.Pod, pvcLister listersv1.PersistentVolumeClaimLister, log logrus.FieldLogger) ([]restic.VolumeBackupPlan, error) {
+	ret := _m.Called(pod, pvcLister, log)
+
+	var r0 []restic.VolumeBackupPlan
+	if rf, ok := ret.Get(0).(func(*corev1.Pod, listersv1.PersistentVolumeClaimLister, logrus.FieldLogger) []restic.VolumeBackupPlan); ok {
+		r0 = rf(pod, pvcLister, log)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]restic.VolumeBackupPlan)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*corev1.Pod, listersv1.PersistentVolumeClaimLister, logrus.FieldLogger) error); ok {
+		r1 = rf(pod, pvcLister, log)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
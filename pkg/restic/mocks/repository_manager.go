@@ -0,0 +1,164 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import context "context"
+import mock "github.com/stretchr/testify/mock"
+import restic "github.com/heptio/velero/pkg/restic"
+
+import v1 "github.com/heptio/velero/pkg/apis/velero/v1"
+
+// RepositoryManager is an autogenerated mock type for the RepositoryManager type
+type RepositoryManager struct {
+	mock.Mock
+}
+
+// AddRepoKey provides a mock function with given fields: repo, newPassword
+func (_m *RepositoryManager) AddRepoKey(repo *v1.ResticRepository, newPassword []byte) error {
+	ret := _m.Called(repo, newPassword)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1.ResticRepository, []byte) error); ok {
+		r0 = rf(repo, newPassword)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CheckRepo provides a mock function with given fields: repo, readDataPercent
+func (_m *RepositoryManager) CheckRepo(repo *v1.ResticRepository, readDataPercent int) error {
+	ret := _m.Called(repo, readDataPercent)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1.ResticRepository, int) error); ok {
+		r0 = rf(repo, readDataPercent)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Forget provides a mock function with given fields: _a0, _a1
+func (_m *RepositoryManager) Forget(_a0 context.Context, _a1 restic.SnapshotIdentifier) error {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, restic.SnapshotIdentifier) error); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InitRepo provides a mock function with given fields: repo
+func (_m *RepositoryManager) InitRepo(repo *v1.ResticRepository) error {
+	ret := _m.Called(repo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1.ResticRepository) error); ok {
+		r0 = rf(repo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewBackupper provides a mock function with given fields: _a0, _a1
+func (_m *RepositoryManager) NewBackupper(_a0 context.Context, _a1 *v1.Backup) (restic.Backupper, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 restic.Backupper
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.Backup) restic.Backupper); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(restic.Backupper)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.Backup) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRestorer provides a mock function with given fields: _a0, _a1
+func (_m *RepositoryManager) NewRestorer(_a0 context.Context, _a1 *v1.Restore) (restic.Restorer, error) {
+	ret := _m.Called(_a0, _a1)
+
+	var r0 restic.Restorer
+	if rf, ok := ret.Get(0).(func(context.Context, *v1.Restore) restic.Restorer); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(restic.Restorer)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *v1.Restore) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PruneRepo provides a mock function with given fields: repo
+func (_m *RepositoryManager) PruneRepo(repo *v1.ResticRepository) error {
+	ret := _m.Called(repo)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1.ResticRepository) error); ok {
+		r0 = rf(repo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RemoveRepoKey provides a mock function with given fields: repo, keyID
+func (_m *RepositoryManager) RemoveRepoKey(repo *v1.ResticRepository, keyID string) error {
+	ret := _m.Called(repo, keyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*v1.ResticRepository, string) error); ok {
+		r0 = rf(repo, keyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RepositoryStatus provides a mock function with given fields: repo
+func (_m *RepositoryManager) RepositoryStatus(repo *v1.ResticRepository) (restic.RepoStatus, error) {
+	ret := _m.Called(repo)
+
+	var r0 restic.RepoStatus
+	if rf, ok := ret.Get(0).(func(*v1.ResticRepository) restic.RepoStatus); ok {
+		r0 = rf(repo)
+	} else {
+		r0 = ret.Get(0).(restic.RepoStatus)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*v1.ResticRepository) error); ok {
+		r1 = rf(repo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,39 @@
+// Code generated by mockery v1.0.0
+package mocks
+
+import corev1 "k8s.io/api/core/v1"
+import logrus "github.com/sirupsen/logrus"
+import mock "github.com/stretchr/testify/mock"
+import restic "github.com/heptio/velero/pkg/restic"
+
+import v1 "github.com/heptio/velero/pkg/apis/velero/v1"
+
+// Restorer is an autogenerated mock type for the Restorer type
+type Restorer struct {
+	mock.Mock
+}
+
+// RestorePodVolumes provides a mock function with given fields: restore, pod, sourceNamespace, backupLocation, log
+func (_m *Restorer) RestorePodVolumes(restore *v1.Restore, pod *corev1.Pod, sourceNamespace string, backupLocation string, log logrus.FieldLogger) (*restic.PodVolumeRestoreResult, []error) {
+	ret := _m.Called(restore, pod, sourceNamespace, backupLocation, log)
+
+	var r0 *restic.PodVolumeRestoreResult
+	if rf, ok := ret.Get(0).(func(*v1.Restore, *corev1.Pod, string, string, logrus.FieldLogger) *restic.PodVolumeRestoreResult); ok {
+		r0 = rf(restore, pod, sourceNamespace, backupLocation, log)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*restic.PodVolumeRestoreResult)
+		}
+	}
+
+	var r1 []error
+	if rf, ok := ret.Get(1).(func(*v1.Restore, *corev1.Pod, string, string, logrus.FieldLogger) []error); ok {
+		r1 = rf(restore, pod, sourceNamespace, backupLocation, log)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]error)
+		}
+	}
+
+	return r0, r1
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
@@ -42,8 +43,14 @@ type RepositoryManager interface {
 	// InitRepo initializes a repo with the specified name and identifier.
 	InitRepo(repo *velerov1api.ResticRepository) error
 
-	// CheckRepo checks the specified repo for errors.
-	CheckRepo(repo *velerov1api.ResticRepository) error
+	// CheckRepo checks the specified repo for errors, and reads and
+	// verifies readDataPercent percent of its data.
+	CheckRepo(repo *velerov1api.ResticRepository, readDataPercent int) error
+
+	// RepositoryStatus probes the specified repo and returns whether it
+	// can be used, and if not, why. An error is returned if the repo
+	// couldn't be probed, or if the reason it can't be used is unknown.
+	RepositoryStatus(repo *velerov1api.ResticRepository) (RepoStatus, error)
 
 	// PruneRepo deletes unused data from a repo.
 	PruneRepo(repo *velerov1api.ResticRepository) error
@@ -52,6 +59,14 @@ type RepositoryManager interface {
 	// available snapshots in a repo.
 	Forget(context.Context, SnapshotIdentifier) error
 
+	// AddRepoKey adds a key with the specified password to a repo,
+	// so that it can be opened with either its current password
+	// or the new one.
+	AddRepoKey(repo *velerov1api.ResticRepository, newPassword []byte) error
+
+	// RemoveRepoKey removes the key with the specified ID from a repo.
+	RemoveRepoKey(repo *velerov1api.ResticRepository, keyID string) error
+
 	BackupperFactory
 
 	RestorerFactory
@@ -74,6 +89,9 @@ type RestorerFactory interface {
 type repositoryManager struct {
 	namespace                    string
 	veleroClient                 clientset.Interface
+	podClient                    corev1client.PodsGetter
+	namespaceClient              corev1client.NamespacesGetter
+	eventRecorder                EventRecorder
 	secretsLister                corev1listers.SecretLister
 	repoLister                   velerov1listers.ResticRepositoryLister
 	repoInformerSynced           cache.InformerSynced
@@ -84,6 +102,8 @@ type repositoryManager struct {
 	repoEnsurer                  *repositoryEnsurer
 	fileSystem                   filesystem.Interface
 	ctx                          context.Context
+	extraInitFlags               []string
+	nonBackupableVolumeFilters   []volumeFilter
 }
 
 // NewRepositoryManager constructs a RepositoryManager.
@@ -91,15 +111,28 @@ func NewRepositoryManager(
 	ctx context.Context,
 	namespace string,
 	veleroClient clientset.Interface,
+	podClient corev1client.PodsGetter,
+	namespaceClient corev1client.NamespacesGetter,
+	eventRecorder EventRecorder,
 	secretsInformer cache.SharedIndexInformer,
 	repoInformer velerov1informers.ResticRepositoryInformer,
 	repoClient velerov1client.ResticRepositoriesGetter,
 	backupLocationInformer velerov1informers.BackupStorageLocationInformer,
+	extraInitFlags []string,
+	nonBackupableVolumes []string,
 	log logrus.FieldLogger,
 ) (RepositoryManager, error) {
+	nonBackupableVolumeFilters, err := parseNonBackupableVolumes(nonBackupableVolumes)
+	if err != nil {
+		return nil, err
+	}
+
 	rm := &repositoryManager{
 		namespace:                    namespace,
 		veleroClient:                 veleroClient,
+		podClient:                    podClient,
+		namespaceClient:              namespaceClient,
+		eventRecorder:                eventRecorder,
 		secretsLister:                corev1listers.NewSecretLister(secretsInformer.GetIndexer()),
 		repoLister:                   repoInformer.Lister(),
 		repoInformerSynced:           repoInformer.Informer().HasSynced,
@@ -107,6 +140,8 @@ func NewRepositoryManager(
 		backupLocationInformerSynced: backupLocationInformer.Informer().HasSynced,
 		log:                          log,
 		ctx:                          ctx,
+		extraInitFlags:               extraInitFlags,
+		nonBackupableVolumeFilters:   nonBackupableVolumeFilters,
 
 		repoLocker:  newRepoLocker(),
 		repoEnsurer: newRepositoryEnsurer(repoInformer, repoClient, log),
@@ -167,15 +202,55 @@ func (rm *repositoryManager) InitRepo(repo *velerov1api.ResticRepository) error
 	rm.repoLocker.LockExclusive(repo.Name)
 	defer rm.repoLocker.UnlockExclusive(repo.Name)
 
-	return rm.exec(InitCommand(repo.Spec.ResticIdentifier), repo.Spec.BackupStorageLocation)
+	cmd := InitCommand(repo.Spec.ResticIdentifier)
+	cmd.ExtraFlags = append(cmd.ExtraFlags, rm.extraInitFlags...)
+
+	return rm.exec(cmd, repo.Spec.BackupStorageLocation)
 }
 
-func (rm *repositoryManager) CheckRepo(repo *velerov1api.ResticRepository) error {
+func (rm *repositoryManager) CheckRepo(repo *velerov1api.ResticRepository, readDataPercent int) error {
 	// restic check requires an exclusive lock
 	rm.repoLocker.LockExclusive(repo.Name)
 	defer rm.repoLocker.UnlockExclusive(repo.Name)
 
-	return rm.exec(CheckCommand(repo.Spec.ResticIdentifier), repo.Spec.BackupStorageLocation)
+	// the form of the flag that reads part of the repository's data depends
+	// on the version of restic.
+	var resticVersion string
+	if readDataPercent > 0 && readDataPercent < 100 {
+		var err error
+		if resticVersion, err = GetVersion(); err != nil {
+			return errors.Wrap(err, "error getting restic version")
+		}
+	}
+
+	return rm.exec(CheckCommand(repo.Spec.ResticIdentifier, readDataPercent, resticVersion), repo.Spec.BackupStorageLocation)
+}
+
+func (rm *repositoryManager) RepositoryStatus(repo *velerov1api.ResticRepository) (RepoStatus, error) {
+	// restic snapshots requires a non-exclusive lock
+	rm.repoLocker.Lock(repo.Name)
+	defer rm.repoLocker.Unlock(repo.Name)
+
+	cmd := ListSnapshotsCommand(repo.Spec.ResticIdentifier)
+
+	file, err := rm.prepareCmd(cmd, repo.Spec.BackupStorageLocation)
+	if err != nil {
+		return RepoStatus{}, err
+	}
+	// ignore error since there's nothing we can do and it's a temp file.
+	defer os.Remove(file)
+
+	_, stderr, err := rm.runCmd(cmd)
+	if err == nil {
+		return RepoStatus{Health: RepoHealthHealthy}, nil
+	}
+
+	health, ok := classifyRepoError(stderr)
+	if !ok {
+		return RepoStatus{}, err
+	}
+
+	return RepoStatus{Health: health, Message: strings.TrimSpace(stderr)}, nil
 }
 
 func (rm *repositoryManager) PruneRepo(repo *velerov1api.ResticRepository) error {
@@ -207,28 +282,70 @@ func (rm *repositoryManager) Forget(ctx context.Context, snapshot SnapshotIdenti
 	return rm.exec(ForgetCommand(repo.Spec.ResticIdentifier, snapshot.SnapshotID), repo.Spec.BackupStorageLocation)
 }
 
+func (rm *repositoryManager) AddRepoKey(repo *velerov1api.ResticRepository, newPassword []byte) error {
+	file, err := tempPasswordFile(rm.fileSystem, fmt.Sprintf("%s-%s-new", CredentialsSecretName, repo.Name), newPassword)
+	if err != nil {
+		return err
+	}
+	// ignore error since there's nothing we can do and it's a temp file.
+	defer os.Remove(file)
+
+	// restic key add requires an exclusive lock
+	rm.repoLocker.LockExclusive(repo.Name)
+	defer rm.repoLocker.UnlockExclusive(repo.Name)
+
+	return rm.exec(AddKeyCommand(repo.Spec.ResticIdentifier, file), repo.Spec.BackupStorageLocation)
+}
+
+func (rm *repositoryManager) RemoveRepoKey(repo *velerov1api.ResticRepository, keyID string) error {
+	// restic key remove requires an exclusive lock
+	rm.repoLocker.LockExclusive(repo.Name)
+	defer rm.repoLocker.UnlockExclusive(repo.Name)
+
+	return rm.exec(RemoveKeyCommand(repo.Spec.ResticIdentifier, keyID), repo.Spec.BackupStorageLocation)
+}
+
 func (rm *repositoryManager) exec(cmd *Command, backupLocation string) error {
-	file, err := TempCredentialsFile(rm.secretsLister, rm.namespace, cmd.RepoName(), rm.fileSystem)
+	file, err := rm.prepareCmd(cmd, backupLocation)
 	if err != nil {
 		return err
 	}
 	// ignore error since there's nothing we can do and it's a temp file.
 	defer os.Remove(file)
 
+	_, _, err = rm.runCmd(cmd)
+	return err
+}
+
+// prepareCmd sets the password file and any environment variables that cmd
+// needs to access its repository. It returns the path of the password file,
+// which the caller must remove once cmd has run.
+func (rm *repositoryManager) prepareCmd(cmd *Command, backupLocation string) (string, error) {
+	file, err := TempCredentialsFile(rm.secretsLister, rm.namespace, cmd.RepoName(), rm.fileSystem)
+	if err != nil {
+		return "", err
+	}
+
 	cmd.PasswordFile = file
 
 	if strings.HasPrefix(cmd.RepoIdentifier, "azure") {
 		if !cache.WaitForCacheSync(rm.ctx.Done(), rm.backupLocationInformerSynced) {
-			return errors.New("timed out waiting for cache to sync")
+			os.Remove(file)
+			return "", errors.New("timed out waiting for cache to sync")
 		}
 
 		env, err := AzureCmdEnv(rm.backupLocationLister, rm.namespace, backupLocation)
 		if err != nil {
-			return err
+			os.Remove(file)
+			return "", err
 		}
 		cmd.Env = env
 	}
 
+	return file, nil
+}
+
+func (rm *repositoryManager) runCmd(cmd *Command) (string, string, error) {
 	stdout, stderr, err := veleroexec.RunCommand(cmd.Cmd())
 	rm.log.WithFields(logrus.Fields{
 		"repository": cmd.RepoName(),
@@ -237,8 +354,8 @@ func (rm *repositoryManager) exec(cmd *Command, backupLocation string) error {
 		"stderr":     stderr,
 	}).Debugf("Ran restic command")
 	if err != nil {
-		return errors.Wrapf(err, "error running command=%s, stdout=%s, stderr=%s", cmd.String(), stdout, stderr)
+		return stdout, stderr, errors.Wrapf(err, "error running command=%s, stdout=%s, stderr=%s", cmd.String(), stdout, stderr)
 	}
 
-	return nil
+	return stdout, stderr, nil
 }
@@ -0,0 +1,116 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"strings"
+)
+
+// RepoHealth describes whether a restic repository can be used.
+type RepoHealth string
+
+const (
+	// RepoHealthHealthy means the repository could be opened and read.
+	RepoHealthHealthy RepoHealth = "Healthy"
+
+	// RepoHealthNotInitialized means the repository's backend could be
+	// reached, but there's no repository in it.
+	RepoHealthNotInitialized RepoHealth = "NotInitialized"
+
+	// RepoHealthLocked means the repository is exclusively locked, e.g.
+	// by a prune that's in progress or one that didn't finish.
+	RepoHealthLocked RepoHealth = "Locked"
+
+	// RepoHealthAuthError means the repository's password, or the
+	// credentials for its backend, were rejected.
+	RepoHealthAuthError RepoHealth = "AuthError"
+
+	// RepoHealthUnreachable means the repository's backend couldn't be
+	// reached.
+	RepoHealthUnreachable RepoHealth = "Unreachable"
+)
+
+// RepoStatus is the result of probing a restic repository.
+type RepoStatus struct {
+	Health RepoHealth
+
+	// Message is restic's output explaining the health, or empty if the
+	// repository is healthy.
+	Message string
+}
+
+// repoHealthMatchers are checked in order against the output of a failed
+// restic command, and the first one with a matching substring classifies the
+// failure. Unreachable is checked before NotInitialized because restic
+// suggests that there may be no repository whenever it can't read the
+// repository's config file, including when the backend can't be reached.
+var repoHealthMatchers = []struct {
+	health     RepoHealth
+	substrings []string
+}{
+	{
+		health: RepoHealthLocked,
+		substrings: []string{
+			"repository is already locked",
+		},
+	},
+	{
+		health: RepoHealthAuthError,
+		substrings: []string{
+			"wrong password or no key found",
+			"AccessDenied",
+			"Access Denied",
+			"InvalidAccessKeyId",
+			"SignatureDoesNotMatch",
+			"AuthenticationFailed",
+			"403 Forbidden",
+		},
+	},
+	{
+		health: RepoHealthUnreachable,
+		substrings: []string{
+			"no such host",
+			"connection refused",
+			"i/o timeout",
+			"network is unreachable",
+			"TLS handshake timeout",
+			"NoSuchBucket",
+			"The specified bucket does not exist",
+			"ContainerNotFound",
+		},
+	},
+	{
+		health: RepoHealthNotInitialized,
+		substrings: []string{
+			"Is there a repository at the following location?",
+		},
+	},
+}
+
+// classifyRepoError returns the health of a repository given the stderr of a
+// restic command that failed against it, or false if it can't be classified.
+func classifyRepoError(stderr string) (RepoHealth, bool) {
+	for _, matcher := range repoHealthMatchers {
+		for _, substring := range matcher.substrings {
+			if strings.Contains(stderr, substring) {
+				return matcher.health, true
+			}
+		}
+	}
+
+	return "", false
+}
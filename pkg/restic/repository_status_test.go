@@ -0,0 +1,104 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyRepoError(t *testing.T) {
+	tests := []struct {
+		name           string
+		stderr         string
+		expectedHealth RepoHealth
+		expectedOK     bool
+	}{
+		{
+			name: "missing config file in s3 is not initialized",
+			stderr: `Fatal: unable to open config file: Stat: The specified key does not exist.
+Is there a repository at the following location?
+s3:s3.amazonaws.com/bucket/restic/ns-1
+`,
+			expectedHealth: RepoHealthNotInitialized,
+			expectedOK:     true,
+		},
+		{
+			name: "missing config file on a local filesystem is not initialized",
+			stderr: `Fatal: unable to open config file: stat /tmp/repo/config: no such file or directory
+Is there a repository at the following location?
+/tmp/repo
+`,
+			expectedHealth: RepoHealthNotInitialized,
+			expectedOK:     true,
+		},
+		{
+			name:           "wrong password is an auth error",
+			stderr:         "Fatal: wrong password or no key found\n",
+			expectedHealth: RepoHealthAuthError,
+			expectedOK:     true,
+		},
+		{
+			name: "rejected object store credentials are an auth error",
+			stderr: `Fatal: unable to open config file: Stat: Access Denied.
+Is there a repository at the following location?
+s3:s3.amazonaws.com/bucket/restic/ns-1
+`,
+			expectedHealth: RepoHealthAuthError,
+			expectedOK:     true,
+		},
+		{
+			name: "exclusive lock is locked",
+			stderr: `unable to create lock in backend: repository is already locked exclusively by PID 1234 on velero-abc by root (UID 0, GID 0)
+lock was created at 2019-05-01 10:00:00 (2m3s ago)
+storage ID 8a1c4f2e
+`,
+			expectedHealth: RepoHealthLocked,
+			expectedOK:     true,
+		},
+		{
+			name: "unknown host is unreachable, even though restic suggests there's no repository",
+			stderr: `Fatal: unable to open config file: Stat: Get https://minio.velero.svc:9000/velero/?location=: dial tcp: lookup minio.velero.svc on 10.96.0.10:53: no such host
+Is there a repository at the following location?
+s3:http://minio.velero.svc:9000/velero/restic/ns-1
+`,
+			expectedHealth: RepoHealthUnreachable,
+			expectedOK:     true,
+		},
+		{
+			name:           "refused connection is unreachable",
+			stderr:         "Fatal: unable to open config file: Stat: Get http://10.0.0.1:9000/velero/: dial tcp 10.0.0.1:9000: connect: connection refused\n",
+			expectedHealth: RepoHealthUnreachable,
+			expectedOK:     true,
+		},
+		{
+			name:       "unrecognized error isn't classified",
+			stderr:     "Fatal: load <snapshot/0123456789>: invalid data returned\n",
+			expectedOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			health, ok := classifyRepoError(test.stderr)
+
+			assert.Equal(t, test.expectedOK, ok)
+			assert.Equal(t, test.expectedHealth, health)
+		})
+	}
+}
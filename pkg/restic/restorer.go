@@ -18,7 +18,9 @@ package restic
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -32,8 +34,10 @@ import (
 
 // Restorer can execute restic restores of volumes in a pod.
 type Restorer interface {
-	// RestorePodVolumes restores all annotated volumes in a pod.
-	RestorePodVolumes(restore *velerov1api.Restore, pod *corev1api.Pod, sourceNamespace, backupLocation string, log logrus.FieldLogger) []error
+	// RestorePodVolumes restores all annotated volumes in a pod, except for
+	// any that the restore's annotations exclude, and returns the outcome for
+	// each of them along with any errors.
+	RestorePodVolumes(restore *velerov1api.Restore, pod *corev1api.Pod, sourceNamespace, backupLocation string, log logrus.FieldLogger) (*PodVolumeRestoreResult, []error)
 }
 
 type restorer struct {
@@ -83,16 +87,59 @@ func newRestorer(
 	return r
 }
 
-func (r *restorer) RestorePodVolumes(restore *velerov1api.Restore, pod *corev1api.Pod, sourceNamespace, backupLocation string, log logrus.FieldLogger) []error {
-	// get volumes to restore from pod's annotations
-	volumesToRestore := GetPodSnapshotAnnotations(pod)
+func (r *restorer) RestorePodVolumes(restore *velerov1api.Restore, pod *corev1api.Pod, sourceNamespace, backupLocation string, log logrus.FieldLogger) (*PodVolumeRestoreResult, []error) {
+	result, errs := r.restorePodVolumes(restore, pod, sourceNamespace, backupLocation, log)
+	if result != nil {
+		recordVolumeFailures(r.repoManager.eventRecorder, objectReference("Restore", restore), VolumeRestoreFailedReason, pod, result.Volumes)
+	}
+	return result, errs
+}
+
+func (r *restorer) restorePodVolumes(restore *velerov1api.Restore, pod *corev1api.Pod, sourceNamespace, backupLocation string, log logrus.FieldLogger) (*PodVolumeRestoreResult, []error) {
+	// get volumes to restore from pod's annotations, filtered by the
+	// restore's annotations
+	volumesToRestore, skippedVolumes := GetVolumesToRestore(restore, pod, pod.Namespace)
+	if len(volumesToRestore) == 0 && len(skippedVolumes) == 0 {
+		return nil, nil
+	}
+
+	// create the PodVolumeRestores in a consistent order, since the restic
+	// daemonset pod processes them in the order they're created.
+	volumes := make([]string, 0, len(volumesToRestore))
+	for volume := range volumesToRestore {
+		volumes = append(volumes, volume)
+	}
+	sort.Strings(volumes)
+
+	var (
+		result        = &PodVolumeRestoreResult{}
+		volumeIndexes = make(map[string]int)
+	)
+
+	// the result has both restored and skipped volumes, in name order.
+	allVolumes := append(append([]string{}, volumes...), skippedVolumes...)
+	sort.Strings(allVolumes)
+	for i, volume := range allVolumes {
+		volumeIndexes[volume] = i
+		result.Volumes = append(result.Volumes, VolumeResult{Volume: volume})
+	}
+
+	for _, volume := range skippedVolumes {
+		log.Infof("Not restoring volume %s in pod %s/%s because the restore's %s or %s annotation excludes it", volume, pod.Namespace, pod.Name, RestoreVolumesAnnotation, SkipRestoreVolumesAnnotation)
+		volumeResult := &result.Volumes[volumeIndexes[volume]]
+		volumeResult.Status, volumeResult.Message = VolumeResultSkipped, "excluded by the restore's annotations"
+	}
 	if len(volumesToRestore) == 0 {
-		return nil
+		return result, nil
 	}
 
 	repo, err := r.repoEnsurer.EnsureRepo(r.ctx, restore.Namespace, sourceNamespace, backupLocation)
 	if err != nil {
-		return []error{err}
+		for _, volume := range volumes {
+			volumeResult := &result.Volumes[volumeIndexes[volume]]
+			volumeResult.Status, volumeResult.SnapshotID, volumeResult.Message = VolumeResultFailed, volumesToRestore[volume], err.Error()
+		}
+		return result, []error{err}
 	}
 
 	// get a single non-exclusive lock since we'll wait for all individual
@@ -108,16 +155,23 @@ func (r *restorer) RestorePodVolumes(restore *velerov1api.Restore, pod *corev1ap
 
 	var (
 		errs        []error
+		startTimes  = make(map[string]time.Time)
 		numRestores int
 	)
 
-	for volume, snapshot := range volumesToRestore {
-		volumeRestore := newPodVolumeRestore(restore, pod, volume, snapshot, backupLocation, repo.Spec.ResticIdentifier)
+	for _, volume := range volumes {
+		volumeResult := &result.Volumes[volumeIndexes[volume]]
+		volumeResult.SnapshotID = volumesToRestore[volume]
+
+		volumeRestore := newPodVolumeRestore(restore, pod, volume, volumesToRestore[volume], backupLocation, repo.Spec.ResticIdentifier)
 
 		if err := errorOnly(r.repoManager.veleroClient.VeleroV1().PodVolumeRestores(volumeRestore.Namespace).Create(volumeRestore)); err != nil {
 			errs = append(errs, errors.WithStack(err))
+			volumeResult.Status, volumeResult.Message = VolumeResultFailed, err.Error()
 			continue
 		}
+
+		startTimes[volume] = time.Now()
 		numRestores++
 	}
 
@@ -128,17 +182,32 @@ ForEachVolume:
 			errs = append(errs, errors.New("timed out waiting for all PodVolumeRestores to complete"))
 			break ForEachVolume
 		case res := <-resultsChan:
+			volumeResult := &result.Volumes[volumeIndexes[res.Spec.Volume]]
+			volumeResult.Duration = time.Since(startTimes[res.Spec.Volume])
+			delete(startTimes, res.Spec.Volume)
+
 			if res.Status.Phase == velerov1api.PodVolumeRestorePhaseFailed {
 				errs = append(errs, errors.Errorf("pod volume restore failed: %s", res.Status.Message))
+				volumeResult.Status, volumeResult.Message = VolumeResultFailed, res.Status.Message
+				continue
 			}
+
+			volumeResult.Status = VolumeResultCompleted
+			log.Infof("Restored volume %s in pod %s/%s from snapshot %s", res.Spec.Volume, pod.Namespace, pod.Name, res.Spec.SnapshotID)
 		}
 	}
 
+	for volume, startTime := range startTimes {
+		volumeResult := &result.Volumes[volumeIndexes[volume]]
+		volumeResult.Status, volumeResult.Message = VolumeResultFailed, "timed out waiting for the volume's restore to complete"
+		volumeResult.Duration = time.Since(startTime)
+	}
+
 	r.resultsLock.Lock()
 	delete(r.results, resultsKey(pod.Namespace, pod.Name))
 	r.resultsLock.Unlock()
 
-	return errs
+	return result, errs
 }
 
 func newPodVolumeRestore(restore *velerov1api.Restore, pod *corev1api.Pod, volume, snapshot, backupLocation, repoIdentifier string) *velerov1api.PodVolumeRestore {
@@ -0,0 +1,187 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	core "k8s.io/client-go/testing"
+
+	velerov1api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/generated/clientset/versioned/fake"
+	informers "github.com/heptio/velero/pkg/generated/informers/externalversions"
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestRestorePodVolumesCreatesRestoresInVolumeNameOrder(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		repoInformer    = sharedInformers.Velero().V1().ResticRepositories()
+		log             = velerotest.NewLogger()
+	)
+
+	repo := &velerov1api.ResticRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      "repo-1",
+			Labels:    repoLabels("ns-1", "default"),
+		},
+		Status: velerov1api.ResticRepositoryStatus{
+			Phase: velerov1api.ResticRepositoryPhaseReady,
+		},
+	}
+	require.NoError(t, repoInformer.Informer().GetStore().Add(repo))
+
+	var createdVolumes []string
+	client.PrependReactor("create", "podvolumerestores", func(action core.Action) (bool, runtime.Object, error) {
+		pvr := action.(core.CreateAction).GetObject().(*velerov1api.PodVolumeRestore)
+		createdVolumes = append(createdVolumes, pvr.Spec.Volume)
+		// the fake clientset doesn't support generateName, so don't
+		// pass the create through to it.
+		return true, pvr, nil
+	})
+
+	// cancel the context up front so RestorePodVolumes doesn't wait for
+	// the restores to complete.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := &restorer{
+		ctx: ctx,
+		repoManager: &repositoryManager{
+			namespace:     "velero",
+			veleroClient:  client,
+			eventRecorder: &fakeEventRecorder{},
+			repoLocker:    newRepoLocker(),
+		},
+		repoEnsurer: newRepositoryEnsurer(repoInformer, client.VeleroV1(), log),
+		results:     make(map[string]chan *velerov1api.PodVolumeRestore),
+	}
+
+	pod := &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns-1",
+			Name:      "pod-1",
+			Annotations: map[string]string{
+				podAnnotationPrefix + "vol-c": "snapshot-c",
+				podAnnotationPrefix + "vol-a": "snapshot-a",
+				podAnnotationPrefix + "vol-d": "snapshot-d",
+				podAnnotationPrefix + "vol-b": "snapshot-b",
+			},
+		},
+	}
+
+	restore := velerotest.NewTestRestore("velero", "restore-1", velerov1api.RestorePhaseInProgress).Restore
+
+	result, errs := r.RestorePodVolumes(restore, pod, "ns-1", "default", log)
+
+	// the only error is from the cancelled context.
+	assert.Len(t, errs, 1)
+	assert.Equal(t, []string{"vol-a", "vol-b", "vol-c", "vol-d"}, createdVolumes)
+
+	// none of the restores completed before the context was cancelled.
+	require.NotNil(t, result)
+	require.Len(t, result.Volumes, 4)
+	for _, volumeResult := range result.Volumes {
+		assert.Equal(t, VolumeResultFailed, volumeResult.Status)
+	}
+}
+
+func TestRestorePodVolumesOnlyRestoresIncludedVolumes(t *testing.T) {
+	var (
+		client          = fake.NewSimpleClientset()
+		sharedInformers = informers.NewSharedInformerFactory(client, 0)
+		repoInformer    = sharedInformers.Velero().V1().ResticRepositories()
+		log             = velerotest.NewLogger()
+	)
+
+	repo := &velerov1api.ResticRepository{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      "repo-1",
+			Labels:    repoLabels("ns-1", "default"),
+		},
+		Status: velerov1api.ResticRepositoryStatus{
+			Phase: velerov1api.ResticRepositoryPhaseReady,
+		},
+	}
+	require.NoError(t, repoInformer.Informer().GetStore().Add(repo))
+
+	var createdVolumes []string
+	client.PrependReactor("create", "podvolumerestores", func(action core.Action) (bool, runtime.Object, error) {
+		pvr := action.(core.CreateAction).GetObject().(*velerov1api.PodVolumeRestore)
+		createdVolumes = append(createdVolumes, pvr.Spec.Volume)
+		// the fake clientset doesn't support generateName, so don't
+		// pass the create through to it.
+		return true, pvr, nil
+	})
+
+	// cancel the context up front so RestorePodVolumes doesn't wait for
+	// the restores to complete.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := &restorer{
+		ctx: ctx,
+		repoManager: &repositoryManager{
+			namespace:     "velero",
+			veleroClient:  client,
+			eventRecorder: &fakeEventRecorder{},
+			repoLocker:    newRepoLocker(),
+		},
+		repoEnsurer: newRepositoryEnsurer(repoInformer, client.VeleroV1(), log),
+		results:     make(map[string]chan *velerov1api.PodVolumeRestore),
+	}
+
+	pod := &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns-1",
+			Name:      "pod-1",
+			Annotations: map[string]string{
+				podAnnotationPrefix + "vol-a": "snapshot-a",
+				podAnnotationPrefix + "vol-b": "snapshot-b",
+				podAnnotationPrefix + "vol-c": "snapshot-c",
+			},
+		},
+	}
+
+	restore := velerotest.NewTestRestore("velero", "restore-1", velerov1api.RestorePhaseInProgress).Restore
+	restore.Annotations = map[string]string{RestoreVolumesAnnotation: "vol-b"}
+
+	result, errs := r.RestorePodVolumes(restore, pod, "ns-1", "default", log)
+
+	// the only error is from the cancelled context.
+	assert.Len(t, errs, 1)
+	assert.Equal(t, []string{"vol-b"}, createdVolumes)
+
+	require.NotNil(t, result)
+	for i := range result.Volumes {
+		result.Volumes[i].Duration = 0
+	}
+	assert.Equal(t, []VolumeResult{
+		{Volume: "vol-a", Status: VolumeResultSkipped, Message: "excluded by the restore's annotations"},
+		{Volume: "vol-b", Status: VolumeResultFailed, SnapshotID: "snapshot-b", Message: "timed out waiting for the volume's restore to complete"},
+		{Volume: "vol-c", Status: VolumeResultSkipped, Message: "excluded by the restore's annotations"},
+	}, result.Volumes)
+}
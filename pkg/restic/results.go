@@ -0,0 +1,87 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restic
+
+import "time"
+
+// VolumeResultStatus is the outcome of backing up or restoring a pod volume.
+type VolumeResultStatus string
+
+const (
+	VolumeResultCompleted VolumeResultStatus = "Completed"
+	VolumeResultFailed    VolumeResultStatus = "Failed"
+	VolumeResultSkipped   VolumeResultStatus = "Skipped"
+)
+
+// VolumeResult describes what happened to one pod volume during a restic
+// backup or restore.
+type VolumeResult struct {
+	// Volume is the name of the volume in the pod.
+	Volume string
+
+	// Status is the outcome for the volume.
+	Status VolumeResultStatus
+
+	// SnapshotID is the ID of the restic snapshot that the volume was
+	// backed up to or restored from, if any.
+	SnapshotID string
+
+	// Duration is how long Velero waited for the volume's
+	// PodVolumeBackup or PodVolumeRestore to finish, including any time
+	// it spent queued in the restic daemonset. It's zero for volumes
+	// that didn't have one.
+	Duration time.Duration
+
+	// Message explains a Failed or Skipped status.
+	Message string
+}
+
+// PodVolumeBackupResult is the outcome of backing up a pod's volumes with
+// restic.
+type PodVolumeBackupResult struct {
+	// Volumes has a result for each volume listed in the pod's
+	// backup-volumes annotation, in the order they're listed.
+	Volumes []VolumeResult
+}
+
+// Snapshots returns a map, of volume name -> snapshot ID, of the volumes
+// that were backed up.
+func (r *PodVolumeBackupResult) Snapshots() map[string]string {
+	if r == nil {
+		return nil
+	}
+
+	var snapshots map[string]string
+	for _, res := range r.Volumes {
+		if res.Status != VolumeResultCompleted {
+			continue
+		}
+		if snapshots == nil {
+			snapshots = make(map[string]string)
+		}
+		snapshots[res.Volume] = res.SnapshotID
+	}
+	return snapshots
+}
+
+// PodVolumeRestoreResult is the outcome of restoring a pod's volumes with
+// restic.
+type PodVolumeRestoreResult struct {
+	// Volumes has a result for each volume with a restic snapshot in the
+	// pod's annotations, in volume name order.
+	Volumes []VolumeResult
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	addRestoreLabelsConfigName = "velero.io/add-restore-labels"
+
+	// keys in the add-restore-labels plugin's config map data. Both are
+	// optional.
+	restoreNameLabelKey      = "restoreNameLabel"
+	restoreTimestampLabelKey = "restoreTimestampLabel"
+
+	// restoreTimestampFormat is the format of the restore timestamp label's
+	// value. Label values can't contain colons, so RFC 3339 can't be used.
+	restoreTimestampFormat = "20060102T150405Z"
+)
+
+// podTemplateMetadataPaths are the paths to the pod template metadata of
+// the workload kinds that have one.
+var podTemplateMetadataPaths = [][]string{
+	// deployments, statefulsets, daemonsets, replicasets, jobs and
+	// replicationcontrollers
+	{"spec", "template", "metadata"},
+	// cronjobs
+	{"spec", "jobTemplate", "spec", "template", "metadata"},
+}
+
+type addRestoreLabelsAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+
+	// the action applies to every item, so the config map is read once per
+	// restore, rather than once per item. Restores are processed one at a
+	// time, so only the current restore's is kept.
+	lock       sync.Mutex
+	restoreUID types.UID
+	config     *corev1api.ConfigMap
+	configRead bool
+}
+
+// NewAddRestoreLabelsAction returns an ItemAction that labels restored items,
+// and the pod templates of restored workloads, with the restore's name and
+// creation timestamp, if the plugin's config map exists.
+func NewAddRestoreLabelsAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &addRestoreLabelsAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *addRestoreLabelsAction) AppliesTo() (ResourceSelector, error) {
+	// an empty selector applies to all resources.
+	return ResourceSelector{}, nil
+}
+
+func (a *addRestoreLabelsAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	config, err := a.getConfig(restore)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// this action is enabled by the presence of the config map, since all
+	// of its settings are optional.
+	if config == nil {
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	restoreLabels := map[string]string{
+		api.RestoreNameLabel: restore.Name,
+	}
+	if key := config.Data[restoreNameLabelKey]; key != "" {
+		restoreLabels = map[string]string{key: restore.Name}
+	}
+	if key := config.Data[restoreTimestampLabelKey]; key != "" {
+		restoreLabels[key] = restore.CreationTimestamp.UTC().Format(restoreTimestampFormat)
+	}
+
+	a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	}).Debugf("Adding restore labels %v to item", restoreLabels)
+
+	item.SetLabels(mergeLabels(item.GetLabels(), restoreLabels))
+
+	for _, path := range podTemplateMetadataPaths {
+		// only workloads have pod template metadata
+		if _, found, _ := unstructured.NestedMap(item.UnstructuredContent(), path...); !found {
+			continue
+		}
+
+		templateLabels, _, err := unstructured.NestedStringMap(item.UnstructuredContent(), append(path, "labels")...)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "error getting item's pod template labels")
+		}
+
+		if err := unstructured.SetNestedStringMap(item.UnstructuredContent(), mergeLabels(templateLabels, restoreLabels), append(path, "labels")...); err != nil {
+			return nil, nil, errors.Wrap(err, "unable to set item's pod template labels")
+		}
+	}
+
+	return item, nil, nil
+}
+
+// mergeLabels returns labels with newLabels added to it.
+func mergeLabels(labels, newLabels map[string]string) map[string]string {
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	for k, v := range newLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// getConfig returns the plugin's config map, or nil if there isn't one,
+// reading it only for the first item of each restore. Errors aren't cached,
+// so they're retried for the next item.
+func (a *addRestoreLabelsAction) getConfig(restore *api.Restore) (*corev1api.ConfigMap, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if a.configRead && a.restoreUID == restore.UID {
+		return a.config, nil
+	}
+
+	config, err := getPluginConfig(addRestoreLabelsConfigName, a.configMapClient)
+	if err != nil {
+		return nil, err
+	}
+
+	a.restoreUID, a.config, a.configRead = restore.UID, config, true
+
+	return config, nil
+}
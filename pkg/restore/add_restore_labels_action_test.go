@@ -0,0 +1,153 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestAddRestoreLabelsActionExecute(t *testing.T) {
+	podTemplate := func(labels map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"labels": labels},
+			"spec":     map[string]interface{}{},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name:        "no config map leaves the item unchanged",
+			obj:         NewTestUnstructured().WithKind("ConfigMap").WithName("cm-1").Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("ConfigMap").WithName("cm-1").Unstructured,
+		},
+		{
+			name:      "plain object gets the restore name label",
+			configMap: newPluginConfigMap("cm-1", addRestoreLabelsConfigName, nil),
+			obj: NewTestUnstructured().WithKind("ConfigMap").WithName("cm-1").
+				WithMetadataField("labels", map[string]interface{}{"app": "foo"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("ConfigMap").WithName("cm-1").
+				WithMetadataField("labels", map[string]interface{}{"app": "foo", "velero.io/restore-name": "restore-1"}).
+				Unstructured,
+		},
+		{
+			name:      "deployment's pod template gets the restore name label",
+			configMap: newPluginConfigMap("cm-1", addRestoreLabelsConfigName, nil),
+			obj: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", podTemplate(map[string]interface{}{"app": "foo"})).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithMetadataField("labels", map[string]interface{}{"velero.io/restore-name": "restore-1"}).
+				WithSpecField("template", podTemplate(map[string]interface{}{"app": "foo", "velero.io/restore-name": "restore-1"})).
+				Unstructured,
+		},
+		{
+			name:      "cronjob's pod template gets the restore name label",
+			configMap: newPluginConfigMap("cm-1", addRestoreLabelsConfigName, nil),
+			obj: NewTestUnstructured().WithKind("CronJob").WithName("cronjob-1").
+				WithSpecField("jobTemplate", map[string]interface{}{
+					"spec": map[string]interface{}{"template": podTemplate(nil)},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("CronJob").WithName("cronjob-1").
+				WithMetadataField("labels", map[string]interface{}{"velero.io/restore-name": "restore-1"}).
+				WithSpecField("jobTemplate", map[string]interface{}{
+					"spec": map[string]interface{}{"template": podTemplate(map[string]interface{}{"velero.io/restore-name": "restore-1"})},
+				}).
+				Unstructured,
+		},
+		{
+			name: "configured label keys are used",
+			configMap: newPluginConfigMap("cm-1", addRestoreLabelsConfigName, map[string]string{
+				"restoreNameLabel":      "example.com/restore",
+				"restoreTimestampLabel": "example.com/restored-at",
+			}),
+			obj: NewTestUnstructured().WithKind("ConfigMap").WithName("cm-1").Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("ConfigMap").WithName("cm-1").
+				WithMetadataField("labels", map[string]interface{}{
+					"example.com/restore":     "restore-1",
+					"example.com/restored-at": "20190102T030405Z",
+				}).
+				Unstructured,
+		},
+	}
+
+	restore := velerotest.NewTestRestore("velero", "restore-1", "").Restore
+	restore.CreationTimestamp = metav1.NewTime(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewAddRestoreLabelsAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, restore)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+func TestAddRestoreLabelsActionReadsConfigOncePerRestore(t *testing.T) {
+	configMapClient := new(fakeConfigMapClient)
+	action := NewAddRestoreLabelsAction(velerotest.NewLogger(), configMapClient)
+
+	restore1 := velerotest.NewTestRestore("velero", "restore-1", "").Restore
+	restore1.UID = "uid-1"
+	restore2 := velerotest.NewTestRestore("velero", "restore-2", "").Restore
+	restore2.UID = "uid-2"
+
+	// without a config map, items after the first of a restore are
+	// unchanged without listing config maps again.
+	for i := 0; i < 3; i++ {
+		obj := NewTestUnstructured().WithName("svc-1").Unstructured
+		res, _, err := action.Execute(obj, restore1)
+		require.NoError(t, err)
+		assert.Equal(t, obj, res)
+	}
+	assert.Equal(t, 1, configMapClient.listCalls)
+
+	// a config map created for a later restore is read for it.
+	configMapClient.configMaps = append(configMapClient.configMaps, *newPluginConfigMap("cm-1", addRestoreLabelsConfigName, nil))
+
+	res, _, err := action.Execute(NewTestUnstructured().WithName("svc-1").Unstructured, restore2)
+	require.NoError(t, err)
+	assert.Equal(t, "restore-2", res.(*unstructured.Unstructured).GetLabels()[api.RestoreNameLabel])
+	assert.Equal(t, 2, configMapClient.listCalls)
+}
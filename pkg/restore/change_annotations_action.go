@@ -0,0 +1,263 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	changeAnnotationsConfigName = "velero.io/change-annotations"
+
+	// allResources is the resource in an annotation rule that matches
+	// items of every resource.
+	allResources = "*"
+)
+
+type changeAnnotationsAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+
+	// the action applies to every item, so the rules are read from the
+	// config map once per restore, rather than once per item. Restores are
+	// processed one at a time, so only the current restore's are kept.
+	lock       sync.Mutex
+	restoreUID types.UID
+	rules      []annotationRule
+	rulesRead  bool
+}
+
+// annotationRule sets the annotation key to value on items of resource,
+// or removes it if remove is true. resource is either a resource name,
+// optionally qualified with its group, or allResources.
+type annotationRule struct {
+	resource string
+	key      string
+	value    string
+	remove   bool
+}
+
+// NewChangeAnnotationsAction returns an ItemAction that sets or removes
+// annotations on restored items according to the rules in the plugin's
+// config map.
+func NewChangeAnnotationsAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &changeAnnotationsAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *changeAnnotationsAction) AppliesTo() (ResourceSelector, error) {
+	// rules can apply to any resource, so they're matched against each
+	// item's resource in Execute.
+	return ResourceSelector{}, nil
+}
+
+func (a *changeAnnotationsAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeAnnotationsAction")
+	defer a.logger.Info("Done executing changeAnnotationsAction")
+
+	rules, err := a.getRules(restore)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(rules) == 0 {
+		a.logger.Debug("No annotation rules found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	// the item's resource isn't passed to restore item actions, so it's
+	// guessed from its kind, the same way kubectl does without discovery.
+	gvr, _ := meta.UnsafeGuessKindToResource(item.GroupVersionKind())
+	groupResource := gvr.GroupResource()
+
+	// resolve a single rule per annotation key, so that a rule for the
+	// item's resource takes precedence over a rule for all resources
+	// whatever either of them does.
+	effectiveRules := make(map[string]annotationRule)
+	precedence := make(map[string]int)
+	for _, rule := range rules {
+		p := rulePrecedence(rule, groupResource)
+		if p < 0 {
+			continue
+		}
+
+		if _, ok := effectiveRules[rule.key]; !ok || p > precedence[rule.key] {
+			effectiveRules[rule.key] = rule
+			precedence[rule.key] = p
+		}
+	}
+
+	keys := make([]string, 0, len(effectiveRules))
+	for key := range effectiveRules {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	annotations := item.GetAnnotations()
+	changed := false
+
+	for _, key := range keys {
+		rule := effectiveRules[key]
+
+		if rule.remove {
+			if _, ok := annotations[rule.key]; ok {
+				log.Infof("Removing item's annotation %s", rule.key)
+				delete(annotations, rule.key)
+				changed = true
+			}
+			continue
+		}
+
+		if val, ok := annotations[rule.key]; ok && val != rule.value {
+			log.Infof("Updating item's annotation %s from %q to %q", rule.key, val, rule.value)
+			annotations[rule.key] = rule.value
+			changed = true
+		}
+	}
+
+	if !changed {
+		log.Debug("No annotation rules matched the item")
+		return obj, nil, nil
+	}
+
+	item.SetAnnotations(annotations)
+
+	return item, nil, nil
+}
+
+// getRules returns the annotation rules in the plugin's config map, reading
+// and parsing them only for the first item of each restore. Errors aren't
+// cached, so they're retried for the next item.
+func (a *changeAnnotationsAction) getRules(restore *api.Restore) ([]annotationRule, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if restore != nil && a.rulesRead && a.restoreUID == restore.UID {
+		return a.rules, nil
+	}
+
+	config, err := getPluginConfig(changeAnnotationsConfigName, a.configMapClient)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []annotationRule
+	if config != nil {
+		if rules, err = parseAnnotationRules(config.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if restore != nil {
+		a.restoreUID, a.rules, a.rulesRead = restore.UID, rules, true
+	}
+
+	return rules, nil
+}
+
+// rulePrecedence returns how specifically rule matches items of
+// groupResource: 0 for a rule for all resources, 1 for a rule for the
+// resource's name and 2 for a rule for its group-qualified name. It returns
+// -1 if rule doesn't apply to groupResource.
+func rulePrecedence(rule annotationRule, groupResource schema.GroupResource) int {
+	switch rule.resource {
+	case allResources:
+		return 0
+	case groupResource.String():
+		// for a core resource, this is the same as its name.
+		if groupResource.Group == "" {
+			return 1
+		}
+		return 2
+	case groupResource.Resource:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// parseAnnotationRules parses the plugin's config map data into annotation
+// rules, ordered by resource and annotation key. ConfigMap keys can't
+// contain slashes, which annotation keys often do, so each rule is a value
+// of the form <resource>/<annotation key>=<value>, or
+// <resource>/<annotation key>- to remove the annotation, as with kubectl
+// annotate. Keys are only used in error messages.
+func parseAnnotationRules(data map[string]string) ([]annotationRule, error) {
+	var rules []annotationRule
+	for key, val := range data {
+		parts := strings.SplitN(val, "/", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("annotation rule %s has invalid value %q, expected <resource>/<annotation key>=<value> or <resource>/<annotation key>-", key, val)
+		}
+
+		rule := annotationRule{resource: parts[0]}
+
+		// annotation keys can't contain an equals sign or end with a dash,
+		// so neither is ambiguous.
+		if kv := strings.SplitN(parts[1], "=", 2); len(kv) == 2 {
+			rule.key, rule.value = kv[0], kv[1]
+		} else if strings.HasSuffix(parts[1], "-") {
+			rule.key, rule.remove = strings.TrimSuffix(parts[1], "-"), true
+		}
+
+		if rule.key == "" {
+			return nil, errors.Errorf("annotation rule %s has invalid value %q, expected <resource>/<annotation key>=<value> or <resource>/<annotation key>-", key, val)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	// sort so that which of two rules for the same resource and key takes
+	// effect doesn't depend on map iteration order.
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].resource != rules[j].resource {
+			return rules[i].resource < rules[j].resource
+		}
+		if rules[i].key != rules[j].key {
+			return rules[i].key < rules[j].key
+		}
+		return rules[i].value < rules[j].value
+	})
+
+	return rules, nil
+}
@@ -0,0 +1,253 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeAnnotationsActionAppliesTo(t *testing.T) {
+	// the config map isn't read, so its rules don't limit the resources
+	// the action applies to.
+	configMapClient := new(fakeConfigMapClient)
+	configMapClient.configMaps = append(configMapClient.configMaps, *newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+		"rule-1": "services/external-dns.alpha.kubernetes.io/hostname=app.example.com",
+	}))
+
+	action := NewChangeAnnotationsAction(velerotest.NewLogger(), configMapClient)
+
+	res, err := action.AppliesTo()
+
+	assert.NoError(t, err)
+	assert.Equal(t, ResourceSelector{}, res)
+}
+
+func TestChangeAnnotationsActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+		},
+		{
+			name: "matching rule rewrites the annotation's value",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"hostname": "services/external-dns.alpha.kubernetes.io/hostname=app.dr.example.com",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"external-dns.alpha.kubernetes.io/hostname": "app.example.com"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"external-dns.alpha.kubernetes.io/hostname": "app.dr.example.com"}).
+				Unstructured,
+		},
+		{
+			name: "rule with a group-qualified resource rewrites the annotation's value",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"issuer": "ingresses.extensions/cert-manager.io/cluster-issuer=issuer-2",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("extensions/v1beta1").WithKind("Ingress").WithName("ing-1").
+				WithAnnotationValues(map[string]string{"cert-manager.io/cluster-issuer": "issuer-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("extensions/v1beta1").WithKind("Ingress").WithName("ing-1").
+				WithAnnotationValues(map[string]string{"cert-manager.io/cluster-issuer": "issuer-2"}).
+				Unstructured,
+		},
+		{
+			name: "matching delete rule removes the annotation",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"lb": "services/service.beta.kubernetes.io/aws-load-balancer-internal-",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{
+					"service.beta.kubernetes.io/aws-load-balancer-internal": "true",
+					"example.com/owner": "team-1",
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+		},
+		{
+			name: "wildcard rule applies to every resource, and a specific rule takes precedence over it",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"all":      "*/example.com/owner=team-2",
+				"services": "services/example.com/owner=team-3",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-3"}).
+				Unstructured,
+		},
+		{
+			name: "specific set rule takes precedence over a wildcard delete rule",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"all":      "*/example.com/owner-",
+				"services": "services/example.com/owner=team-2",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-2"}).
+				Unstructured,
+		},
+		{
+			name: "wildcard delete rule applies to a resource whose specific rule is for another annotation",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"all":      "*/example.com/owner-",
+				"services": "services/example.com/team=team-2",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1", "example.com/team": "team-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/team": "team-2"}).
+				Unstructured,
+		},
+		{
+			name: "specific delete rule takes precedence over a wildcard set rule",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"all":      "*/example.com/owner=team-2",
+				"services": "services/example.com/owner-",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1", "foo": "bar"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"foo": "bar"}).
+				Unstructured,
+		},
+		{
+			name: "group-qualified rule takes precedence over a rule for the resource's name",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"name":      "ingresses/cert-manager.io/cluster-issuer=issuer-2",
+				"qualified": "ingresses.extensions/cert-manager.io/cluster-issuer=issuer-3",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("extensions/v1beta1").WithKind("Ingress").WithName("ing-1").
+				WithAnnotationValues(map[string]string{"cert-manager.io/cluster-issuer": "issuer-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("extensions/v1beta1").WithKind("Ingress").WithName("ing-1").
+				WithAnnotationValues(map[string]string{"cert-manager.io/cluster-issuer": "issuer-3"}).
+				Unstructured,
+		},
+		{
+			name: "rule for a different resource leaves the item unchanged",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"owner": "configmaps/example.com/owner=team-2",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+		},
+		{
+			name: "rule for an annotation the item doesn't have leaves the item unchanged",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"hostname": "services/external-dns.alpha.kubernetes.io/hostname=app.dr.example.com",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+		},
+		{
+			name: "invalid rule returns an error",
+			configMap: newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+				"owner": "services/example.com/owner",
+			}),
+			obj: NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+				WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+				Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeAnnotationsAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+func TestChangeAnnotationsActionReadsConfigOncePerRestore(t *testing.T) {
+	configMapClient := new(fakeConfigMapClient)
+	action := NewChangeAnnotationsAction(velerotest.NewLogger(), configMapClient)
+
+	restore1 := velerotest.NewTestRestore("velero", "restore-1", "").Restore
+	restore1.UID = "uid-1"
+	restore2 := velerotest.NewTestRestore("velero", "restore-2", "").Restore
+	restore2.UID = "uid-2"
+
+	// without a config map, items after the first of a restore are
+	// unchanged without listing config maps again.
+	for i := 0; i < 3; i++ {
+		obj := NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").Unstructured
+		res, _, err := action.Execute(obj, restore1)
+		require.NoError(t, err)
+		assert.Equal(t, obj, res)
+	}
+	assert.Equal(t, 1, configMapClient.listCalls)
+
+	// a config map created for a later restore is read for it.
+	configMapClient.configMaps = append(configMapClient.configMaps, *newPluginConfigMap("cm-1", changeAnnotationsConfigName, map[string]string{
+		"rule-1": "services/example.com/owner=team-2",
+	}))
+
+	obj := NewTestUnstructured().WithAPIVersion("v1").WithKind("Service").WithName("svc-1").
+		WithAnnotationValues(map[string]string{"example.com/owner": "team-1"}).
+		Unstructured
+	res, _, err := action.Execute(obj, restore2)
+	require.NoError(t, err)
+	assert.Equal(t, "team-2", res.(*unstructured.Unstructured).GetAnnotations()["example.com/owner"])
+	assert.Equal(t, 2, configMapClient.listCalls)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const changeHostPathConfigName = "velero.io/change-host-path"
+
+type changeHostPathAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// hostPathMapping maps hostPath volume paths that start with sourcePrefix to
+// paths that start with targetPrefix instead.
+type hostPathMapping struct {
+	sourcePrefix string
+	targetPrefix string
+}
+
+// NewChangeHostPathAction returns an ItemAction that updates the paths of a
+// pod's hostPath volumes, or those of a workload's pod template, if they
+// start with a prefix that's mapped in the plugin's config map.
+func NewChangeHostPathAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &changeHostPathAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *changeHostPathAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{
+			"pods",
+			"deployments.apps",
+			"statefulsets.apps",
+			"daemonsets.apps",
+			"replicasets.apps",
+			"jobs.batch",
+			"cronjobs.batch",
+			"replicationcontrollers",
+		},
+	}, nil
+}
+
+func (a *changeHostPathAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeHostPathAction")
+	defer a.logger.Info("Done executing changeHostPathAction")
+
+	config, err := getPluginConfig(changeHostPathConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No hostPath mappings found")
+		return obj, nil, nil
+	}
+
+	mappings, err := parseHostPathMappings(config.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	podSpec, err := getPodSpec(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	if podSpec == nil {
+		log.Debug("Item has no pod spec")
+		return obj, nil, nil
+	}
+
+	for _, volume := range mapsIn(podSpec["volumes"]) {
+		hostPath, ok := volume["hostPath"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		path, ok := hostPath["path"].(string)
+		if !ok {
+			continue
+		}
+
+		for _, mapping := range mappings {
+			if !mapping.matches(path) {
+				continue
+			}
+
+			newPath := mapping.targetPrefix + strings.TrimPrefix(path, mapping.sourcePrefix)
+			log.Infof("Updating hostPath volume %v from %s to %s", volume["name"], path, newPath)
+			hostPath["path"] = newPath
+			break
+		}
+	}
+
+	return item, nil, nil
+}
+
+// matches returns true if path is the mapping's source prefix or a path
+// under it, so that a mapping of /mnt/data doesn't match /mnt/database.
+func (m hostPathMapping) matches(path string) bool {
+	if !strings.HasPrefix(path, m.sourcePrefix) {
+		return false
+	}
+
+	return len(path) == len(m.sourcePrefix) ||
+		strings.HasSuffix(m.sourcePrefix, "/") ||
+		path[len(m.sourcePrefix)] == '/'
+}
+
+// parseHostPathMappings returns the mappings in the plugin's config map data,
+// whose values are of the form <source prefix>:<target prefix>, and whose keys
+// are ignored, since config map keys can't contain slashes. The mappings are
+// sorted so that longer source prefixes come first and take precedence.
+func parseHostPathMappings(data map[string]string) ([]hostPathMapping, error) {
+	var mappings []hostPathMapping
+	for key, val := range data {
+		parts := strings.SplitN(val, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.Errorf("hostPath mapping %s has invalid value %q, expected <source prefix>:<target prefix>", key, val)
+		}
+
+		mappings = append(mappings, hostPathMapping{sourcePrefix: parts[0], targetPrefix: parts[1]})
+	}
+
+	sort.Slice(mappings, func(i, j int) bool {
+		if len(mappings[i].sourcePrefix) != len(mappings[j].sourcePrefix) {
+			return len(mappings[i].sourcePrefix) > len(mappings[j].sourcePrefix)
+		}
+		return mappings[i].sourcePrefix < mappings[j].sourcePrefix
+	})
+
+	return mappings, nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeHostPathActionExecute(t *testing.T) {
+	volumes := func(hostPath string) interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"name":     "host-path",
+				"hostPath": map[string]interface{}{"path": hostPath},
+			},
+			map[string]interface{}{
+				"name":     "empty-dir",
+				"emptyDir": map[string]interface{}{},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/mnt/data")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/mnt/data")).
+				Unstructured,
+		},
+		{
+			name:      "pod's hostPath volume with a mapped prefix has its path updated",
+			configMap: newPluginConfigMap("cm", changeHostPathConfigName, map[string]string{"mnt": "/mnt/:/data/"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/mnt/app/logs")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/data/app/logs")).
+				Unstructured,
+		},
+		{
+			name:      "pod's hostPath volume without a mapped prefix is unchanged",
+			configMap: newPluginConfigMap("cm", changeHostPathConfigName, map[string]string{"mnt": "/mnt/:/data/"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/var/log")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/var/log")).
+				Unstructured,
+		},
+		{
+			name:      "mapped prefix without a trailing slash matches the path itself and paths under it",
+			configMap: newPluginConfigMap("cm", changeHostPathConfigName, map[string]string{"mnt": "/mnt/data:/data"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", []interface{}{
+					map[string]interface{}{"name": "vol-1", "hostPath": map[string]interface{}{"path": "/mnt/data"}},
+					map[string]interface{}{"name": "vol-2", "hostPath": map[string]interface{}{"path": "/mnt/data/app"}},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", []interface{}{
+					map[string]interface{}{"name": "vol-1", "hostPath": map[string]interface{}{"path": "/data"}},
+					map[string]interface{}{"name": "vol-2", "hostPath": map[string]interface{}{"path": "/data/app"}},
+				}).
+				Unstructured,
+		},
+		{
+			name:      "mapped prefix doesn't match a path that only starts with the same characters",
+			configMap: newPluginConfigMap("cm", changeHostPathConfigName, map[string]string{"mnt": "/mnt/data:/data"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/mnt/database/x")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/mnt/database/x")).
+				Unstructured,
+		},
+		{
+			name: "longest matching prefix is used",
+			configMap: newPluginConfigMap("cm", changeHostPathConfigName, map[string]string{
+				"mnt":      "/mnt/:/data/",
+				"mnt-logs": "/mnt/logs/:/var/log/",
+			}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/mnt/logs/app")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/var/log/app")).
+				Unstructured,
+		},
+		{
+			name:      "deployment's pod template hostPath volume has its path updated",
+			configMap: newPluginConfigMap("cm", changeHostPathConfigName, map[string]string{"mnt": "/mnt/:/data/"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{"volumes": volumes("/mnt/data")},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{"volumes": volumes("/data/data")},
+				}).
+				Unstructured,
+		},
+		{
+			name:      "mapping without a target prefix returns an error",
+			configMap: newPluginConfigMap("cm", changeHostPathConfigName, map[string]string{"mnt": "/mnt/"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("/mnt/data")).
+				Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeHostPathAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
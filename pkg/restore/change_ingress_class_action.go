@@ -0,0 +1,117 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	changeIngressClassConfigName = "velero.io/change-ingress-class"
+
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+type changeIngressClassAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewChangeIngressClassAction returns an ItemAction that updates an ingress's
+// class if a mapping for it is found in the plugin's config map.
+func NewChangeIngressClassAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &changeIngressClassAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *changeIngressClassAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"ingresses.extensions", "ingresses.networking.k8s.io"},
+	}, nil
+}
+
+func (a *changeIngressClassAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeIngressClassAction")
+	defer a.logger.Info("Done executing changeIngressClassAction")
+
+	config, err := getPluginConfig(changeIngressClassConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No ingress class mappings found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	// spec.ingressClassName replaces the annotation in newer versions of
+	// the ingress API, so check it first.
+	ingressClass, foundInSpec, err := unstructured.NestedString(item.UnstructuredContent(), "spec", "ingressClassName")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting item's spec.ingressClassName")
+	}
+
+	annotations := item.GetAnnotations()
+	ingressClassFromAnnotation, foundInAnnotation := annotations[ingressClassAnnotation]
+	if ingressClass == "" {
+		ingressClass = ingressClassFromAnnotation
+	}
+
+	if ingressClass == "" {
+		log.Debug("Item has no ingress class specified")
+		return obj, nil, nil
+	}
+
+	newIngressClass, ok := config.Data[ingressClass]
+	if !ok {
+		log.Debugf("No mapping found for ingress class %s", ingressClass)
+		return obj, nil, nil
+	}
+
+	log.Infof("Updating item's ingress class to %s", newIngressClass)
+
+	if foundInSpec {
+		if err := unstructured.SetNestedField(item.UnstructuredContent(), newIngressClass, "spec", "ingressClassName"); err != nil {
+			return nil, nil, errors.Wrap(err, "unable to set item's spec.ingressClassName")
+		}
+	}
+
+	if foundInAnnotation {
+		annotations[ingressClassAnnotation] = newIngressClass
+		item.SetAnnotations(annotations)
+	}
+
+	return item, nil, nil
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeIngressClassActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "nginx"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "nginx"}).
+				Unstructured,
+		},
+		{
+			name:      "item with no ingress class is unchanged",
+			configMap: newPluginConfigMap("cm-1", changeIngressClassConfigName, map[string]string{"nginx": "alb"}),
+			obj: NewTestUnstructured().WithName("ing-1").
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("ing-1").
+				WithSpec().
+				Unstructured,
+		},
+		{
+			name:      "item with no mapping for its ingress class is unchanged",
+			configMap: newPluginConfigMap("cm-1", changeIngressClassConfigName, map[string]string{"traefik": "alb"}),
+			obj: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "nginx"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "nginx"}).
+				Unstructured,
+		},
+		{
+			name:      "item with ingress class annotation only has it updated",
+			configMap: newPluginConfigMap("cm-1", changeIngressClassConfigName, map[string]string{"nginx": "alb"}),
+			obj: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "nginx", "foo": "bar"}).
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "alb", "foo": "bar"}).
+				WithSpec().
+				Unstructured,
+		},
+		{
+			name:      "item with spec.ingressClassName only has it updated",
+			configMap: newPluginConfigMap("cm-1", changeIngressClassConfigName, map[string]string{"nginx": "alb"}),
+			obj: NewTestUnstructured().WithName("ing-1").
+				WithSpecField("ingressClassName", "nginx").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("ing-1").
+				WithSpecField("ingressClassName", "alb").
+				Unstructured,
+		},
+		{
+			name:      "item with spec.ingressClassName and annotation has both updated",
+			configMap: newPluginConfigMap("cm-1", changeIngressClassConfigName, map[string]string{"nginx": "alb"}),
+			obj: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "nginx"}).
+				WithSpecField("ingressClassName", "nginx").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("ing-1").
+				WithAnnotationValues(map[string]string{ingressClassAnnotation: "alb"}).
+				WithSpecField("ingressClassName", "alb").
+				Unstructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeIngressClassAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	schedulingv1beta1client "k8s.io/client-go/kubernetes/typed/scheduling/v1beta1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	changePriorityClassConfigName = "velero.io/change-priority-class"
+
+	// skipPriorityClassValidationKey is a reserved key in the plugin's config
+	// map data. It can't be mistaken for a priority class mapping, since
+	// priority class names can't contain uppercase letters.
+	skipPriorityClassValidationKey = "skipValidation"
+)
+
+type changePriorityClassAction struct {
+	logger              logrus.FieldLogger
+	configMapClient     corev1client.ConfigMapInterface
+	priorityClassClient schedulingv1beta1client.PriorityClassInterface
+}
+
+// NewChangePriorityClassAction returns an ItemAction that updates the priority
+// class of a pod, or of a workload's pod template, if a mapping for it is found
+// in the plugin's config map. Mapping to an empty name removes the priority
+// class, so the pod gets the default priority.
+func NewChangePriorityClassAction(
+	logger logrus.FieldLogger,
+	configMapClient corev1client.ConfigMapInterface,
+	priorityClassClient schedulingv1beta1client.PriorityClassInterface,
+) ItemAction {
+	return &changePriorityClassAction{
+		logger:              logger,
+		configMapClient:     configMapClient,
+		priorityClassClient: priorityClassClient,
+	}
+}
+
+func (a *changePriorityClassAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{
+			"pods",
+			"deployments.apps",
+			"statefulsets.apps",
+			"daemonsets.apps",
+			"replicasets.apps",
+			"jobs.batch",
+			"cronjobs.batch",
+			"replicationcontrollers",
+		},
+	}, nil
+}
+
+func (a *changePriorityClassAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changePriorityClassAction")
+	defer a.logger.Info("Done executing changePriorityClassAction")
+
+	config, err := getPluginConfig(changePriorityClassConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No priority class mappings found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	podSpec, err := getPodSpec(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	if podSpec == nil {
+		log.Debug("Item has no pod spec")
+		return obj, nil, nil
+	}
+
+	priorityClass, _ := podSpec["priorityClassName"].(string)
+	if priorityClass == "" {
+		log.Debug("Item has no priority class specified")
+		return obj, nil, nil
+	}
+
+	newPriorityClass, ok := config.Data[priorityClass]
+	if !ok || priorityClass == skipPriorityClassValidationKey {
+		log.Debugf("No mapping found for priority class %s", priorityClass)
+		return obj, nil, nil
+	}
+
+	// the pod's priority is computed from its priority class at admission,
+	// and it's rejected if one is already set that doesn't match.
+	delete(podSpec, "priority")
+
+	if newPriorityClass == "" {
+		log.Infof("Removing item's priority class %s", priorityClass)
+		delete(podSpec, "priorityClassName")
+		return item, nil, nil
+	}
+
+	// validate that the new priority class exists, unless configured not to
+	// (e.g. because priority classes are being restored after workloads).
+	if config.Data[skipPriorityClassValidationKey] == "true" {
+		log.Warnf("Not validating that priority class %s exists because %s is set", newPriorityClass, skipPriorityClassValidationKey)
+	} else if _, err := a.priorityClassClient.Get(newPriorityClass, metav1.GetOptions{}); err != nil {
+		return nil, nil, errors.Wrapf(err, "error getting priority class %s from API", newPriorityClass)
+	}
+
+	log.Infof("Updating item's priority class name from %s to %s", priorityClass, newPriorityClass)
+	podSpec["priorityClassName"] = newPriorityClass
+
+	return item, nil, nil
+}
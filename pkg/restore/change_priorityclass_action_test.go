@@ -0,0 +1,157 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	schedulingv1beta1api "k8s.io/api/scheduling/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	schedulingv1beta1client "k8s.io/client-go/kubernetes/typed/scheduling/v1beta1"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangePriorityClassActionExecute(t *testing.T) {
+	tests := []struct {
+		name            string
+		configMap       *corev1api.ConfigMap
+		priorityClasses []string
+		obj             runtime.Unstructured
+		expectedErr     bool
+		expectedRes     runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				Unstructured,
+		},
+		{
+			name:      "item with no mapping for its priority class is unchanged",
+			configMap: newPluginConfigMap("cm-1", changePriorityClassConfigName, map[string]string{"class-2": "class-3"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				Unstructured,
+		},
+		{
+			name:            "pod with a mapping has its priority class updated and its priority removed",
+			configMap:       newPluginConfigMap("cm-1", changePriorityClassConfigName, map[string]string{"class-1": "class-2"}),
+			priorityClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				WithSpecField("priority", int64(1000)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-2").
+				Unstructured,
+		},
+		{
+			name:            "deployment's pod template with a mapping has its priority class updated",
+			configMap:       newPluginConfigMap("cm-1", changePriorityClassConfigName, map[string]string{"class-1": "class-2"}),
+			priorityClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{"priorityClassName": "class-1"},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{"priorityClassName": "class-2"},
+				}).
+				Unstructured,
+		},
+		{
+			name:      "mapping to an empty name removes the priority class",
+			configMap: newPluginConfigMap("cm-1", changePriorityClassConfigName, map[string]string{"class-1": ""}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				WithSpecField("priority", int64(1000)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpec().
+				Unstructured,
+		},
+		{
+			name:      "mapping to a priority class that doesn't exist returns an error",
+			configMap: newPluginConfigMap("cm-1", changePriorityClassConfigName, map[string]string{"class-1": "class-2"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				Unstructured,
+			expectedErr: true,
+		},
+		{
+			name:      "mapping to a priority class that doesn't exist succeeds when validation is skipped",
+			configMap: newPluginConfigMap("cm-1", changePriorityClassConfigName, map[string]string{"class-1": "class-2", "skipValidation": "true"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("priorityClassName", "class-2").
+				Unstructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangePriorityClassAction(
+				velerotest.NewLogger(),
+				configMapClient,
+				&fakePriorityClassClient{names: test.priorityClasses},
+			)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+// fakePriorityClassClient is a PriorityClassInterface whose Get returns
+// a priority class if its name is in names, or a not-found error otherwise.
+type fakePriorityClassClient struct {
+	schedulingv1beta1client.PriorityClassInterface
+
+	names []string
+}
+
+func (c *fakePriorityClassClient) Get(name string, opts metav1.GetOptions) (*schedulingv1beta1api.PriorityClass, error) {
+	for _, n := range c.names {
+		if n == name {
+			return &schedulingv1beta1api.PriorityClass{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+		}
+	}
+
+	return nil, apierrors.NewNotFound(schedulingv1beta1api.Resource("priorityclasses"), name)
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	changeReferencedConfigConfigName = "velero.io/change-referenced-config"
+
+	// the prefixes of the keys in the plugin's config map data, which
+	// distinguish config map name mappings from secret name mappings, since
+	// a config map and a secret can have the same name.
+	configMapMappingPrefix = "configMap."
+	secretMappingPrefix    = "secret."
+)
+
+// podTemplateSpecPaths are the paths to the pod template spec of the
+// workload kinds that have one.
+var podTemplateSpecPaths = [][]string{
+	// deployments, statefulsets, daemonsets, replicasets, jobs and
+	// replicationcontrollers
+	{"spec", "template", "spec"},
+	// cronjobs
+	{"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+type changeReferencedConfigAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewChangeReferencedConfigAction returns an ItemAction that updates the names
+// of the config maps and secrets referenced by a pod, or by a workload's pod
+// template, if mappings for them are found in the plugin's config map.
+func NewChangeReferencedConfigAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &changeReferencedConfigAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *changeReferencedConfigAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{
+			"pods",
+			"deployments.apps",
+			"statefulsets.apps",
+			"daemonsets.apps",
+			"replicasets.apps",
+			"jobs.batch",
+			"cronjobs.batch",
+			"replicationcontrollers",
+		},
+	}, nil
+}
+
+func (a *changeReferencedConfigAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeReferencedConfigAction")
+	defer a.logger.Info("Done executing changeReferencedConfigAction")
+
+	config, err := getPluginConfig(changeReferencedConfigConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No config map or secret name mappings found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	podSpec, err := getPodSpec(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	if podSpec == nil {
+		log.Debug("Item has no pod spec")
+		return obj, nil, nil
+	}
+
+	mappings := &referencedConfigMappings{
+		configMaps: prefixedMappings(config.Data, configMapMappingPrefix),
+		secrets:    prefixedMappings(config.Data, secretMappingPrefix),
+		log:        log,
+	}
+
+	for _, volume := range mapsIn(podSpec["volumes"]) {
+		mappings.changeConfigMapName(volume["configMap"], "name")
+		mappings.changeSecretName(volume["secret"], "secretName")
+
+		if projected, ok := volume["projected"].(map[string]interface{}); ok {
+			for _, source := range mapsIn(projected["sources"]) {
+				mappings.changeConfigMapName(source["configMap"], "name")
+				mappings.changeSecretName(source["secret"], "name")
+			}
+		}
+	}
+
+	for _, containers := range []interface{}{podSpec["initContainers"], podSpec["containers"]} {
+		for _, container := range mapsIn(containers) {
+			for _, envFrom := range mapsIn(container["envFrom"]) {
+				mappings.changeConfigMapName(envFrom["configMapRef"], "name")
+				mappings.changeSecretName(envFrom["secretRef"], "name")
+			}
+
+			for _, env := range mapsIn(container["env"]) {
+				if valueFrom, ok := env["valueFrom"].(map[string]interface{}); ok {
+					mappings.changeConfigMapName(valueFrom["configMapKeyRef"], "name")
+					mappings.changeSecretName(valueFrom["secretKeyRef"], "name")
+				}
+			}
+		}
+	}
+
+	return item, nil, nil
+}
+
+// getPodSpec returns the item's pod spec if it's a pod, or its pod template's
+// spec if it's a workload, or nil if it has neither. The returned map is part
+// of the item's content, so changes to it change the item.
+func getPodSpec(item *unstructured.Unstructured) (map[string]interface{}, error) {
+	paths := podTemplateSpecPaths
+	if item.GetKind() == "Pod" {
+		paths = [][]string{{"spec"}}
+	}
+
+	for _, path := range paths {
+		obj, found, err := unstructured.NestedFieldNoCopy(item.UnstructuredContent(), path...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error getting item's %s", strings.Join(path, "."))
+		}
+		if !found {
+			continue
+		}
+
+		podSpec, ok := obj.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("item's %s is of unexpected type %T", strings.Join(path, "."), obj)
+		}
+		return podSpec, nil
+	}
+
+	return nil, nil
+}
+
+// mapsIn returns the elements of list that are maps, or nil if list isn't
+// a list.
+func mapsIn(list interface{}) []map[string]interface{} {
+	items, ok := list.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var maps []map[string]interface{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			maps = append(maps, m)
+		}
+	}
+	return maps
+}
+
+// prefixedMappings returns the entries in data whose keys start with prefix,
+// with the prefix removed from their keys.
+func prefixedMappings(data map[string]string, prefix string) map[string]string {
+	mappings := make(map[string]string)
+	for k, v := range data {
+		if strings.HasPrefix(k, prefix) {
+			mappings[strings.TrimPrefix(k, prefix)] = v
+		}
+	}
+	return mappings
+}
+
+// referencedConfigMappings changes the names in config map and secret
+// references according to its mappings.
+type referencedConfigMappings struct {
+	configMaps map[string]string
+	secrets    map[string]string
+	log        logrus.FieldLogger
+}
+
+func (m *referencedConfigMappings) changeConfigMapName(ref interface{}, field string) {
+	m.changeName(ref, field, "config map", m.configMaps)
+}
+
+func (m *referencedConfigMappings) changeSecretName(ref interface{}, field string) {
+	m.changeName(ref, field, "secret", m.secrets)
+}
+
+// changeName updates the name in ref's field if ref is a reference and a
+// mapping for the name is found in mappings.
+func (m *referencedConfigMappings) changeName(ref interface{}, field, kind string, mappings map[string]string) {
+	refMap, ok := ref.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	name, ok := refMap[field].(string)
+	if !ok || name == "" {
+		return
+	}
+
+	newName, ok := mappings[name]
+	if !ok {
+		m.log.Debugf("No mapping found for %s %s", kind, name)
+		return
+	}
+
+	m.log.Infof("Updating reference to %s %s to %s", kind, name, newName)
+	refMap[field] = newName
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeReferencedConfigActionExecute(t *testing.T) {
+	volumes := func(configMap, secret, projectedConfigMap, projectedSecret string) interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"name":      "vol-1",
+				"configMap": map[string]interface{}{"name": configMap},
+			},
+			map[string]interface{}{
+				"name":   "vol-2",
+				"secret": map[string]interface{}{"secretName": secret},
+			},
+			map[string]interface{}{
+				"name": "vol-3",
+				"projected": map[string]interface{}{
+					"sources": []interface{}{
+						map[string]interface{}{"configMap": map[string]interface{}{"name": projectedConfigMap}},
+						map[string]interface{}{"secret": map[string]interface{}{"name": projectedSecret}},
+					},
+				},
+			},
+		}
+	}
+
+	containers := func(envFromConfigMap, envFromSecret, envConfigMap, envSecret string) interface{} {
+		return []interface{}{
+			map[string]interface{}{
+				"name": "container-1",
+				"envFrom": []interface{}{
+					map[string]interface{}{"configMapRef": map[string]interface{}{"name": envFromConfigMap}},
+					map[string]interface{}{"secretRef": map[string]interface{}{"name": envFromSecret}},
+				},
+				"env": []interface{}{
+					map[string]interface{}{
+						"name":      "FOO",
+						"valueFrom": map[string]interface{}{"configMapKeyRef": map[string]interface{}{"name": envConfigMap, "key": "foo"}},
+					},
+					map[string]interface{}{
+						"name":      "BAR",
+						"valueFrom": map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": envSecret, "key": "bar"}},
+					},
+					map[string]interface{}{
+						"name":  "BAZ",
+						"value": "baz",
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("cm-1", "secret-1", "cm-1", "secret-1")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("cm-1", "secret-1", "cm-1", "secret-1")).
+				Unstructured,
+		},
+		{
+			name: "pod with volume references has mapped names updated",
+			configMap: newPluginConfigMap("cm", changeReferencedConfigConfigName, map[string]string{
+				"configMap.cm-1":  "cm-2",
+				"secret.secret-1": "secret-2",
+			}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("cm-1", "secret-1", "cm-1", "other-secret")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("cm-2", "secret-2", "cm-2", "other-secret")).
+				Unstructured,
+		},
+		{
+			name: "pod with envFrom and env references has mapped names updated",
+			configMap: newPluginConfigMap("cm", changeReferencedConfigConfigName, map[string]string{
+				"configMap.cm-1":  "cm-2",
+				"secret.secret-1": "secret-2",
+			}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers("cm-1", "secret-1", "cm-1", "secret-1")).
+				WithSpecField("initContainers", containers("cm-1", "other-secret", "other-cm", "secret-1")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers("cm-2", "secret-2", "cm-2", "secret-2")).
+				WithSpecField("initContainers", containers("cm-2", "other-secret", "other-cm", "secret-2")).
+				Unstructured,
+		},
+		{
+			name: "config map mappings aren't applied to secrets and vice versa",
+			configMap: newPluginConfigMap("cm", changeReferencedConfigConfigName, map[string]string{
+				"configMap.secret-1": "cm-2",
+				"secret.cm-1":        "secret-2",
+			}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("cm-1", "secret-1", "cm-1", "secret-1")).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("volumes", volumes("cm-1", "secret-1", "cm-1", "secret-1")).
+				Unstructured,
+		},
+		{
+			name: "deployment's pod template references have mapped names updated",
+			configMap: newPluginConfigMap("cm", changeReferencedConfigConfigName, map[string]string{
+				"configMap.cm-1":  "cm-2",
+				"secret.secret-1": "secret-2",
+			}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{
+						"volumes":    volumes("cm-1", "secret-1", "cm-1", "secret-1"),
+						"containers": containers("cm-1", "secret-1", "cm-1", "secret-1"),
+					},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{
+						"volumes":    volumes("cm-2", "secret-2", "cm-2", "secret-2"),
+						"containers": containers("cm-2", "secret-2", "cm-2", "secret-2"),
+					},
+				}).
+				Unstructured,
+		},
+		{
+			name: "cronjob's pod template references have mapped names updated",
+			configMap: newPluginConfigMap("cm", changeReferencedConfigConfigName, map[string]string{
+				"configMap.cm-1": "cm-2",
+			}),
+			obj: NewTestUnstructured().WithKind("CronJob").WithName("cronjob-1").
+				WithSpecField("jobTemplate", map[string]interface{}{
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"containers": containers("cm-1", "secret-1", "cm-1", "secret-1"),
+							},
+						},
+					},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("CronJob").WithName("cronjob-1").
+				WithSpecField("jobTemplate", map[string]interface{}{
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"containers": containers("cm-2", "secret-1", "cm-2", "secret-1"),
+							},
+						},
+					},
+				}).
+				Unstructured,
+		},
+		{
+			name: "pod spec of an unexpected type returns an error",
+			configMap: newPluginConfigMap("cm", changeReferencedConfigConfigName, map[string]string{
+				"configMap.cm-1": "cm-2",
+			}),
+			obj: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind": "Pod",
+					"spec": "foo",
+				},
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeReferencedConfigAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const changeServiceTypeConfigName = "velero.io/change-service-type"
+
+var validServiceTypes = map[corev1api.ServiceType]bool{
+	corev1api.ServiceTypeClusterIP:    true,
+	corev1api.ServiceTypeNodePort:     true,
+	corev1api.ServiceTypeLoadBalancer: true,
+	corev1api.ServiceTypeExternalName: true,
+}
+
+type changeServiceTypeAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewChangeServiceTypeAction returns an ItemAction that updates a service's
+// type if a mapping for it is found in the plugin's config map.
+func NewChangeServiceTypeAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &changeServiceTypeAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *changeServiceTypeAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"services"},
+	}, nil
+}
+
+func (a *changeServiceTypeAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeServiceTypeAction")
+	defer a.logger.Info("Done executing changeServiceTypeAction")
+
+	config, err := getPluginConfig(changeServiceTypeConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No service type mappings found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	serviceType, _, err := unstructured.NestedString(item.UnstructuredContent(), "spec", "type")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting item's spec.type")
+	}
+	// services with no type specified are ClusterIP services.
+	if serviceType == "" {
+		serviceType = string(corev1api.ServiceTypeClusterIP)
+	}
+
+	newServiceType, ok := config.Data[serviceType]
+	if !ok {
+		log.Debugf("No mapping found for service type %s", serviceType)
+		return obj, nil, nil
+	}
+
+	if !validServiceTypes[corev1api.ServiceType(newServiceType)] {
+		return nil, nil, errors.Errorf("invalid service type %s specified in mapping for service type %s", newServiceType, serviceType)
+	}
+
+	log.Infof("Updating item's service type to %s", newServiceType)
+
+	if err := setServiceType(item, corev1api.ServiceType(newServiceType)); err != nil {
+		return nil, nil, err
+	}
+
+	return item, nil, nil
+}
+
+// setServiceType updates the service's spec.type to serviceType, and removes
+// any fields that aren't valid for a service of that type so the API server
+// accepts it.
+func setServiceType(item *unstructured.Unstructured, serviceType corev1api.ServiceType) error {
+	content := item.UnstructuredContent()
+
+	if err := unstructured.SetNestedField(content, string(serviceType), "spec", "type"); err != nil {
+		return errors.Wrap(err, "unable to set item's spec.type")
+	}
+
+	if serviceType != corev1api.ServiceTypeLoadBalancer {
+		unstructured.RemoveNestedField(content, "spec", "loadBalancerIP")
+		unstructured.RemoveNestedField(content, "spec", "loadBalancerSourceRanges")
+		unstructured.RemoveNestedField(content, "spec", "healthCheckNodePort")
+		unstructured.RemoveNestedField(content, "status", "loadBalancer")
+	}
+
+	if serviceType == corev1api.ServiceTypeClusterIP || serviceType == corev1api.ServiceTypeExternalName {
+		// externalTrafficPolicy is only valid for services that are
+		// exposed outside the cluster via node ports.
+		unstructured.RemoveNestedField(content, "spec", "externalTrafficPolicy")
+
+		ports, _, err := unstructured.NestedSlice(content, "spec", "ports")
+		if err != nil {
+			return errors.Wrap(err, "error getting item's spec.ports")
+		}
+		for _, port := range ports {
+			if p, ok := port.(map[string]interface{}); ok {
+				delete(p, "nodePort")
+			}
+		}
+		if ports != nil {
+			if err := unstructured.SetNestedSlice(content, ports, "spec", "ports"); err != nil {
+				return errors.Wrap(err, "unable to set item's spec.ports")
+			}
+		}
+	}
+
+	if serviceType == corev1api.ServiceTypeExternalName {
+		// ExternalName services must not have a cluster IP, including
+		// "None".
+		unstructured.RemoveNestedField(content, "spec", "clusterIP")
+	} else {
+		// externalName is only valid for ExternalName services.
+		unstructured.RemoveNestedField(content, "spec", "externalName")
+	}
+
+	if serviceType == corev1api.ServiceTypeNodePort || serviceType == corev1api.ServiceTypeLoadBalancer {
+		// headless services can't be exposed via node ports, so a
+		// converted headless service is assigned a cluster IP.
+		if clusterIP, _, _ := unstructured.NestedString(content, "spec", "clusterIP"); clusterIP == corev1api.ClusterIPNone {
+			unstructured.RemoveNestedField(content, "spec", "clusterIP")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeServiceTypeActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				Unstructured,
+		},
+		{
+			name:      "item with no mapping for its type is unchanged",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"NodePort": "ClusterIP"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				Unstructured,
+		},
+		{
+			name:      "LoadBalancer to ClusterIP removes load balancer and node port fields",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"LoadBalancer": "ClusterIP"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				WithSpecField("loadBalancerIP", "1.2.3.4").
+				WithSpecField("loadBalancerSourceRanges", []interface{}{"10.0.0.0/8"}).
+				WithSpecField("externalTrafficPolicy", "Local").
+				WithSpecField("healthCheckNodePort", int64(30000)).
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80), "nodePort": int64(30080)},
+				}).
+				WithStatusField("loadBalancer", map[string]interface{}{}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80)},
+				}).
+				WithStatus().
+				Unstructured,
+		},
+		{
+			name:      "NodePort to ClusterIP removes node port fields",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"NodePort": "ClusterIP"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "NodePort").
+				WithSpecField("externalTrafficPolicy", "Cluster").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80), "nodePort": int64(30080)},
+					map[string]interface{}{"name": "https", "port": int64(443), "nodePort": int64(30443)},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80)},
+					map[string]interface{}{"name": "https", "port": int64(443)},
+				}).
+				Unstructured,
+		},
+		{
+			name:      "LoadBalancer to NodePort keeps node ports and external traffic policy",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"LoadBalancer": "NodePort"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				WithSpecField("loadBalancerIP", "1.2.3.4").
+				WithSpecField("externalTrafficPolicy", "Local").
+				WithSpecField("healthCheckNodePort", int64(30000)).
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80), "nodePort": int64(30080)},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "NodePort").
+				WithSpecField("externalTrafficPolicy", "Local").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "port": int64(80), "nodePort": int64(30080)},
+				}).
+				Unstructured,
+		},
+		{
+			name:      "item with no type is treated as ClusterIP",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"ClusterIP": "NodePort"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("ports", []interface{}{}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{}).
+				Unstructured,
+		},
+		{
+			name:      "headless ClusterIP to NodePort removes the None cluster IP",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"ClusterIP": "NodePort"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("clusterIP", "None").
+				WithSpecField("ports", []interface{}{}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{}).
+				Unstructured,
+		},
+		{
+			name:      "headless ClusterIP to LoadBalancer removes the None cluster IP",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"ClusterIP": "LoadBalancer"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("clusterIP", "None").
+				WithSpecField("ports", []interface{}{}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				WithSpecField("ports", []interface{}{}).
+				Unstructured,
+		},
+		{
+			name:      "headless ClusterIP to ClusterIP keeps the None cluster IP",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"ClusterIP": "ClusterIP"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("clusterIP", "None").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("clusterIP", "None").
+				Unstructured,
+		},
+		{
+			name:      "ExternalName to ClusterIP removes the external name",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"ExternalName": "ClusterIP"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ExternalName").
+				WithSpecField("externalName", "db.example.com").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				Unstructured,
+		},
+		{
+			name:      "mapping to an invalid service type returns an error",
+			configMap: newPluginConfigMap("cm-1", changeServiceTypeConfigName, map[string]string{"LoadBalancer": "Foo"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeServiceTypeAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	changeStorageSizeConfigName = "velero.io/change-storage-size"
+
+	// reserved keys in the change-storage-size plugin's config map data.
+	// All other keys are mappings from an old size to a new size.
+	minimumStorageSizeKey = "minimum"
+	maximumStorageSizeKey = "maximum"
+)
+
+type changeStorageSizeAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewChangeStorageSizeAction returns an ItemAction that updates a PVC's
+// requested storage size based on the mappings and minimum and maximum
+// sizes in the plugin's config map.
+func NewChangeStorageSizeAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &changeStorageSizeAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *changeStorageSizeAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"persistentvolumeclaims"},
+	}, nil
+}
+
+func (a *changeStorageSizeAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeStorageSizeAction")
+	defer a.logger.Info("Done executing changeStorageSizeAction")
+
+	config, err := getPluginConfig(changeStorageSizeConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No storage size config found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	size, found, err := unstructured.NestedString(item.UnstructuredContent(), "spec", "resources", "requests", "storage")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting item's spec.resources.requests.storage")
+	}
+	if !found {
+		log.Debug("Item has no storage size requested")
+		return obj, nil, nil
+	}
+
+	newSize, err := getNewStorageSize(size, config.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if newSize == size {
+		return obj, nil, nil
+	}
+
+	log.Infof("Updating item's requested storage size from %s to %s", size, newSize)
+
+	if err := unstructured.SetNestedField(item.UnstructuredContent(), newSize, "spec", "resources", "requests", "storage"); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to set item's spec.resources.requests.storage")
+	}
+
+	return item, nil, nil
+}
+
+// getNewStorageSize returns the storage size that a PVC requesting size
+// should request instead, based on config: a mapping for size is applied
+// first, and then the result is raised to the minimum or lowered to the
+// maximum if they're specified. size is returned unchanged if none of these
+// apply.
+func getNewStorageSize(size string, config map[string]string) (string, error) {
+	if mapped, ok := config[size]; ok {
+		size = mapped
+	}
+
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return "", errors.Wrapf(err, "error parsing storage size %q", size)
+	}
+
+	var minimum, maximum *resource.Quantity
+	if val := config[minimumStorageSizeKey]; val != "" {
+		q, err := resource.ParseQuantity(val)
+		if err != nil {
+			return "", errors.Wrapf(err, "error parsing %s storage size %q", minimumStorageSizeKey, val)
+		}
+		minimum = &q
+	}
+	if val := config[maximumStorageSizeKey]; val != "" {
+		q, err := resource.ParseQuantity(val)
+		if err != nil {
+			return "", errors.Wrapf(err, "error parsing %s storage size %q", maximumStorageSizeKey, val)
+		}
+		maximum = &q
+	}
+
+	if minimum != nil && maximum != nil && minimum.Cmp(*maximum) > 0 {
+		return "", errors.Errorf("%s storage size %s is greater than %s storage size %s", minimumStorageSizeKey, minimum.String(), maximumStorageSizeKey, maximum.String())
+	}
+
+	switch {
+	case minimum != nil && quantity.Cmp(*minimum) < 0:
+		return minimum.String(), nil
+	case maximum != nil && quantity.Cmp(*maximum) > 0:
+		return maximum.String(), nil
+	default:
+		return size, nil
+	}
+}
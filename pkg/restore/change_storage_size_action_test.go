@@ -0,0 +1,122 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeStorageSizeActionExecute(t *testing.T) {
+	pvcWithSize := func(size string) runtime.Unstructured {
+		return NewTestUnstructured().WithName("pvc-1").
+			WithSpecField("resources", map[string]interface{}{
+				"requests": map[string]interface{}{"storage": size},
+			}).
+			Unstructured
+	}
+
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name:        "no config map leaves the item unchanged",
+			obj:         pvcWithSize("1Gi"),
+			expectedRes: pvcWithSize("1Gi"),
+		},
+		{
+			name:        "item with no requested size is unchanged",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"minimum": "10Gi"}),
+			obj:         NewTestUnstructured().WithName("pvc-1").WithSpec().Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").WithSpec().Unstructured,
+		},
+		{
+			name:        "size below the minimum is clamped up",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"minimum": "10Gi"}),
+			obj:         pvcWithSize("1Gi"),
+			expectedRes: pvcWithSize("10Gi"),
+		},
+		{
+			name:        "size above the maximum is clamped down",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"maximum": "100Gi"}),
+			obj:         pvcWithSize("1Ti"),
+			expectedRes: pvcWithSize("100Gi"),
+		},
+		{
+			name:        "size within the minimum and maximum is unchanged",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"minimum": "10Gi", "maximum": "100Gi"}),
+			obj:         pvcWithSize("50Gi"),
+			expectedRes: pvcWithSize("50Gi"),
+		},
+		{
+			name:        "mapped size is used",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"5Gi": "8Gi"}),
+			obj:         pvcWithSize("5Gi"),
+			expectedRes: pvcWithSize("8Gi"),
+		},
+		{
+			name:        "mapped size is clamped",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"5Gi": "8Gi", "minimum": "10Gi"}),
+			obj:         pvcWithSize("5Gi"),
+			expectedRes: pvcWithSize("10Gi"),
+		},
+		{
+			name:        "invalid mapped size returns an error",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"5Gi": "foo"}),
+			obj:         pvcWithSize("5Gi"),
+			expectedErr: true,
+		},
+		{
+			name:        "invalid minimum returns an error",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"minimum": "foo"}),
+			obj:         pvcWithSize("5Gi"),
+			expectedErr: true,
+		},
+		{
+			name:        "minimum greater than maximum returns an error",
+			configMap:   newPluginConfigMap("cm-1", changeStorageSizeConfigName, map[string]string{"minimum": "10Gi", "maximum": "1Gi"}),
+			obj:         pvcWithSize("5Gi"),
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeStorageSizeAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	storagev1client "k8s.io/client-go/kubernetes/typed/storage/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	changeStorageClassConfigName = "velero.io/change-storage-class"
+
+	// skipStorageClassValidationKey is a reserved key in the plugin's config
+	// map data. It can't be mistaken for a storage class mapping, since
+	// storage class names can't contain uppercase letters.
+	skipStorageClassValidationKey = "skipValidation"
+
+	// betaStorageClassAnnotation is the deprecated annotation that some
+	// provisioners still read instead of spec.storageClassName.
+	betaStorageClassAnnotation = "volume.beta.kubernetes.io/storage-class"
+	storageClassAnnotation     = "volume.kubernetes.io/storage-class"
+)
+
+// storageClassAnnotations are the annotations, in order of precedence, that
+// can specify a PV or PVC's storage class instead of spec.storageClassName.
+var storageClassAnnotations = []string{betaStorageClassAnnotation, storageClassAnnotation}
+
+type changeStorageClassAction struct {
+	logger             logrus.FieldLogger
+	configMapClient    corev1client.ConfigMapInterface
+	storageClassClient storagev1client.StorageClassInterface
+}
+
+// NewChangeStorageClassAction returns an ItemAction that updates a PV or PVC's
+// storage class if a mapping for it is found in the plugin's config map.
+func NewChangeStorageClassAction(
+	logger logrus.FieldLogger,
+	configMapClient corev1client.ConfigMapInterface,
+	storageClassClient storagev1client.StorageClassInterface,
+) ItemAction {
+	return &changeStorageClassAction{
+		logger:             logger,
+		configMapClient:    configMapClient,
+		storageClassClient: storageClassClient,
+	}
+}
+
+func (a *changeStorageClassAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"persistentvolumeclaims", "persistentvolumes"},
+	}, nil
+}
+
+func (a *changeStorageClassAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeStorageClassAction")
+	defer a.logger.Info("Done executing changeStorageClassAction")
+
+	config, err := getPluginConfig(changeStorageClassConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No storage class mappings found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	storageClass, err := getStorageClass(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	if storageClass == "" {
+		log.Debug("Item has no storage class specified")
+		return obj, nil, nil
+	}
+
+	newStorageClass, ok := config.Data[storageClass]
+	if !ok || storageClass == skipStorageClassValidationKey {
+		log.Debugf("No mapping found for storage class %s", storageClass)
+		return obj, nil, nil
+	}
+
+	// validate that the new storage class exists, unless configured not to
+	// (e.g. because storage classes are being restored after PVs and PVCs).
+	if config.Data[skipStorageClassValidationKey] == "true" {
+		log.Warnf("Not validating that storage class %s exists because %s is set", newStorageClass, skipStorageClassValidationKey)
+	} else if _, err := a.storageClassClient.Get(newStorageClass, metav1.GetOptions{}); err != nil {
+		return nil, nil, errors.Wrapf(err, "error getting storage class %s from API", newStorageClass)
+	}
+
+	// include the mapping as fields so the restore log can be filtered
+	// and counted per mapping.
+	log.WithFields(logrus.Fields{
+		"fromStorageClass": storageClass,
+		"toStorageClass":   newStorageClass,
+	}).Infof("Updating item's storage class name to %s", newStorageClass)
+
+	if err := setStorageClass(item, newStorageClass); err != nil {
+		return nil, nil, err
+	}
+
+	return item, nil, nil
+}
+
+// getStorageClass returns the item's storage class, from spec.storageClassName
+// if it's set, or otherwise from one of the storage class annotations.
+func getStorageClass(item *unstructured.Unstructured) (string, error) {
+	// the unstructured helpers are used here since the field is named the same
+	// for both PVs and PVCs.
+	storageClass, _, err := unstructured.NestedString(item.UnstructuredContent(), "spec", "storageClassName")
+	if err != nil {
+		return "", errors.Wrap(err, "error getting item's spec.storageClassName")
+	}
+	if storageClass != "" {
+		return storageClass, nil
+	}
+
+	annotations := item.GetAnnotations()
+	for _, key := range storageClassAnnotations {
+		if annotations[key] != "" {
+			return annotations[key], nil
+		}
+	}
+
+	return "", nil
+}
+
+// setStorageClass updates each of spec.storageClassName and the storage class
+// annotations that are set on the item to storageClass, so they stay consistent.
+// Fields that aren't set are left unset.
+func setStorageClass(item *unstructured.Unstructured, storageClass string) error {
+	_, found, err := unstructured.NestedString(item.UnstructuredContent(), "spec", "storageClassName")
+	if err != nil {
+		return errors.Wrap(err, "error getting item's spec.storageClassName")
+	}
+	if found {
+		if err := unstructured.SetNestedField(item.UnstructuredContent(), storageClass, "spec", "storageClassName"); err != nil {
+			return errors.Wrap(err, "unable to set item's spec.storageClassName")
+		}
+	}
+
+	annotations := item.GetAnnotations()
+	if len(annotations) == 0 {
+		return nil
+	}
+
+	for _, key := range storageClassAnnotations {
+		if _, ok := annotations[key]; ok {
+			annotations[key] = storageClass
+		}
+	}
+	item.SetAnnotations(annotations)
+
+	return nil
+}
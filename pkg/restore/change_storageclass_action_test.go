@@ -0,0 +1,185 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	storagev1api "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	storagev1client "k8s.io/client-go/kubernetes/typed/storage/v1"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeStorageClassActionExecute(t *testing.T) {
+	tests := []struct {
+		name           string
+		configMap      *corev1api.ConfigMap
+		storageClasses []string
+		obj            runtime.Unstructured
+		expectedErr    bool
+		expectedRes    runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+		},
+		{
+			name:      "item with no mapping for its storage class is unchanged",
+			configMap: newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-2": "class-3"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+		},
+		{
+			name:           "item with spec.storageClassName only has it updated",
+			configMap:      newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-1": "class-2"}),
+			storageClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-2").
+				Unstructured,
+		},
+		{
+			name:           "item with beta storage class annotation only has it updated",
+			configMap:      newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-1": "class-2"}),
+			storageClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{betaStorageClassAnnotation: "class-1"}).
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{betaStorageClassAnnotation: "class-2"}).
+				WithSpec().
+				Unstructured,
+		},
+		{
+			name:           "item with storage class annotation only has it updated",
+			configMap:      newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-1": "class-2"}),
+			storageClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{storageClassAnnotation: "class-1"}).
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{storageClassAnnotation: "class-2"}).
+				WithSpec().
+				Unstructured,
+		},
+		{
+			name:           "item with spec.storageClassName and annotations has all of them updated",
+			configMap:      newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-1": "class-2"}),
+			storageClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{
+					betaStorageClassAnnotation: "class-1",
+					storageClassAnnotation:     "class-1",
+					"foo":                      "bar",
+				}).
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{
+					betaStorageClassAnnotation: "class-2",
+					storageClassAnnotation:     "class-2",
+					"foo":                      "bar",
+				}).
+				WithSpecField("storageClassName", "class-2").
+				Unstructured,
+		},
+		{
+			name:      "mapping to a storage class that doesn't exist succeeds when validation is skipped",
+			configMap: newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-1": "class-2", "skipValidation": "true"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-2").
+				Unstructured,
+		},
+		{
+			name:      "mapping to a storage class that doesn't exist returns an error when validation isn't skipped",
+			configMap: newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-1": "class-2", "skipValidation": "false"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+			expectedErr: true,
+		},
+		{
+			name:      "mapping to a storage class that doesn't exist returns an error",
+			configMap: newPluginConfigMap("cm-1", changeStorageClassConfigName, map[string]string{"class-1": "class-2"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithSpecField("storageClassName", "class-1").
+				Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeStorageClassAction(
+				velerotest.NewLogger(),
+				configMapClient,
+				&fakeStorageClassClient{names: test.storageClasses},
+			)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+// fakeStorageClassClient is a StorageClassInterface whose Get returns
+// a storage class if its name is in names, or a not-found error otherwise.
+type fakeStorageClassClient struct {
+	storagev1client.StorageClassInterface
+
+	names []string
+}
+
+func (c *fakeStorageClassClient) Get(name string, opts metav1.GetOptions) (*storagev1api.StorageClass, error) {
+	for _, n := range c.names {
+		if n == name {
+			return &storagev1api.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+		}
+	}
+
+	return nil, apierrors.NewNotFound(storagev1api.Resource("storageclasses"), name)
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/client"
+)
+
+const changeVolumeSnapshotClassConfigName = "velero.io/change-volumesnapshotclass"
+
+// VolumeSnapshotClassGroupVersion is the API group and version of the CSI
+// volume snapshot resources that the change-volumesnapshotclass action reads
+// and validates against.
+var VolumeSnapshotClassGroupVersion = schema.GroupVersion{Group: "snapshot.storage.k8s.io", Version: "v1beta1"}
+
+type changeVolumeSnapshotClassAction struct {
+	logger                    logrus.FieldLogger
+	configMapClient           corev1client.ConfigMapInterface
+	volumeSnapshotClassClient client.Getter
+}
+
+// NewChangeVolumeSnapshotClassAction returns an ItemAction that updates a CSI
+// volume snapshot or volume snapshot content's volume snapshot class if a
+// mapping for it is found in the plugin's config map.
+func NewChangeVolumeSnapshotClassAction(
+	logger logrus.FieldLogger,
+	configMapClient corev1client.ConfigMapInterface,
+	volumeSnapshotClassClient client.Getter,
+) ItemAction {
+	return &changeVolumeSnapshotClassAction{
+		logger:                    logger,
+		configMapClient:           configMapClient,
+		volumeSnapshotClassClient: volumeSnapshotClassClient,
+	}
+}
+
+func (a *changeVolumeSnapshotClassAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{
+			"volumesnapshots.snapshot.storage.k8s.io",
+			"volumesnapshotcontents.snapshot.storage.k8s.io",
+		},
+	}, nil
+}
+
+func (a *changeVolumeSnapshotClassAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing changeVolumeSnapshotClassAction")
+	defer a.logger.Info("Done executing changeVolumeSnapshotClassAction")
+
+	config, err := getPluginConfig(changeVolumeSnapshotClassConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No volume snapshot class mappings found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	// the unstructured helpers are used here since the field is named the
+	// same for both volume snapshots and volume snapshot contents.
+	volumeSnapshotClass, _, err := unstructured.NestedString(item.UnstructuredContent(), "spec", "volumeSnapshotClassName")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting item's spec.volumeSnapshotClassName")
+	}
+	if volumeSnapshotClass == "" {
+		log.Debug("Item has no volume snapshot class specified")
+		return obj, nil, nil
+	}
+
+	newVolumeSnapshotClass, ok := config.Data[volumeSnapshotClass]
+	if !ok {
+		log.Debugf("No mapping found for volume snapshot class %s", volumeSnapshotClass)
+		return obj, nil, nil
+	}
+
+	// validate that new volume snapshot class exists
+	if _, err := a.volumeSnapshotClassClient.Get(newVolumeSnapshotClass, metav1.GetOptions{}); err != nil {
+		return nil, nil, errors.Wrapf(err, "error getting volume snapshot class %s from API", newVolumeSnapshotClass)
+	}
+
+	log.WithFields(logrus.Fields{
+		"fromVolumeSnapshotClass": volumeSnapshotClass,
+		"toVolumeSnapshotClass":   newVolumeSnapshotClass,
+	}).Infof("Updating item's volume snapshot class name to %s", newVolumeSnapshotClass)
+
+	if err := unstructured.SetNestedField(item.UnstructuredContent(), newVolumeSnapshotClass, "spec", "volumeSnapshotClassName"); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to set item's spec.volumeSnapshotClassName")
+	}
+
+	return item, nil, nil
+}
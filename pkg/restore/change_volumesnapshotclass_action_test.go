@@ -0,0 +1,138 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestChangeVolumeSnapshotClassActionExecute(t *testing.T) {
+	tests := []struct {
+		name                  string
+		configMap             *corev1api.ConfigMap
+		volumeSnapshotClasses []string
+		obj                   runtime.Unstructured
+		expectedErr           bool
+		expectedRes           runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpecField("volumeSnapshotClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpecField("volumeSnapshotClassName", "class-1").
+				Unstructured,
+		},
+		{
+			name:      "item with no volume snapshot class is unchanged",
+			configMap: newPluginConfigMap("cm-1", changeVolumeSnapshotClassConfigName, map[string]string{"class-1": "class-2"}),
+			obj: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpec().
+				Unstructured,
+		},
+		{
+			name:      "item with no mapping for its volume snapshot class is unchanged",
+			configMap: newPluginConfigMap("cm-1", changeVolumeSnapshotClassConfigName, map[string]string{"class-2": "class-3"}),
+			obj: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpecField("volumeSnapshotClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpecField("volumeSnapshotClassName", "class-1").
+				Unstructured,
+		},
+		{
+			name:                  "volume snapshot with a mapping has its class updated",
+			configMap:             newPluginConfigMap("cm-1", changeVolumeSnapshotClassConfigName, map[string]string{"class-1": "class-2"}),
+			volumeSnapshotClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpecField("volumeSnapshotClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpecField("volumeSnapshotClassName", "class-2").
+				Unstructured,
+		},
+		{
+			name:                  "volume snapshot content with a mapping has its class updated",
+			configMap:             newPluginConfigMap("cm-1", changeVolumeSnapshotClassConfigName, map[string]string{"class-1": "class-2"}),
+			volumeSnapshotClasses: []string{"class-2"},
+			obj: NewTestUnstructured().WithKind("VolumeSnapshotContent").WithName("content-1").
+				WithSpecField("volumeSnapshotClassName", "class-1").
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("VolumeSnapshotContent").WithName("content-1").
+				WithSpecField("volumeSnapshotClassName", "class-2").
+				Unstructured,
+		},
+		{
+			name:      "mapping to a volume snapshot class that doesn't exist returns an error",
+			configMap: newPluginConfigMap("cm-1", changeVolumeSnapshotClassConfigName, map[string]string{"class-1": "class-2"}),
+			obj: NewTestUnstructured().WithKind("VolumeSnapshot").WithName("snap-1").
+				WithSpecField("volumeSnapshotClassName", "class-1").
+				Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewChangeVolumeSnapshotClassAction(
+				velerotest.NewLogger(),
+				configMapClient,
+				&fakeVolumeSnapshotClassClient{names: test.volumeSnapshotClasses},
+			)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+// fakeVolumeSnapshotClassClient is a client.Getter whose Get returns a volume
+// snapshot class if its name is in names, or a not-found error otherwise.
+type fakeVolumeSnapshotClassClient struct {
+	names []string
+}
+
+func (c *fakeVolumeSnapshotClassClient) Get(name string, opts metav1.GetOptions) (*unstructured.Unstructured, error) {
+	for _, n := range c.names {
+		if n == name {
+			return NewTestUnstructured().WithKind("VolumeSnapshotClass").WithName(name).Unstructured, nil
+		}
+	}
+
+	return nil, apierrors.NewNotFound(VolumeSnapshotClassGroupVersion.WithResource("volumesnapshotclasses").GroupResource(), name)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	pauseDeploymentsConfigName = "velero.io/pause-deployments"
+
+	// pauseDeploymentsNamespacesKey is a key in the pause-deployments plugin's
+	// config map data. It's optional.
+	pauseDeploymentsNamespacesKey = "namespaces"
+
+	// pausedByRestoreAnnotation marks a deployment that the pause-deployments
+	// action paused, with the name of the restore, so that the deployments to
+	// resume can be found later.
+	pausedByRestoreAnnotation = "velero.io/paused-by-restore"
+)
+
+type pauseDeploymentsAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewPauseDeploymentsAction returns an ItemAction that pauses deployments so
+// that they don't roll out when they're restored, if the plugin's config map
+// exists.
+func NewPauseDeploymentsAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &pauseDeploymentsAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *pauseDeploymentsAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"deployments.apps"},
+	}, nil
+}
+
+func (a *pauseDeploymentsAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing pauseDeploymentsAction")
+	defer a.logger.Info("Done executing pauseDeploymentsAction")
+
+	config, err := getPluginConfig(pauseDeploymentsConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// this action is enabled by the presence of the config map, since all
+	// of its settings are optional.
+	if config == nil {
+		a.logger.Debug("No pause-deployments config found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	if item.GetKind() != "Deployment" {
+		return obj, nil, nil
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	if val := config.Data[pauseDeploymentsNamespacesKey]; val != "" && !containsNamespace(val, item.GetNamespace()) {
+		log.Debug("Item's namespace is not included in pause-deployments config")
+		return obj, nil, nil
+	}
+
+	paused, _, err := unstructured.NestedBool(item.UnstructuredContent(), "spec", "paused")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting item's spec.paused")
+	}
+	// deployments that were already paused are left unmarked, so they
+	// aren't resumed along with the ones this action paused.
+	if paused {
+		log.Debug("Deployment is already paused")
+		return obj, nil, nil
+	}
+
+	log.Info("Pausing deployment")
+
+	if err := unstructured.SetNestedField(item.UnstructuredContent(), true, "spec", "paused"); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to set item's spec.paused")
+	}
+
+	annotations := item.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[pausedByRestoreAnnotation] = restore.Name
+	item.SetAnnotations(annotations)
+
+	return item, nil, nil
+}
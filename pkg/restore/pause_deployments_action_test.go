@@ -0,0 +1,117 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestPauseDeploymentsActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the deployment unchanged",
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+		},
+		{
+			name:      "deployment is paused and marked",
+			configMap: newPluginConfigMap("cm-1", pauseDeploymentsConfigName, nil),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithAnnotationValues(map[string]string{pausedByRestoreAnnotation: "restore-1"}).
+				WithSpecField("replicas", int64(3)).
+				WithSpecField("paused", true).
+				Unstructured,
+		},
+		{
+			name:      "deployment that's already paused isn't marked",
+			configMap: newPluginConfigMap("cm-1", pauseDeploymentsConfigName, nil),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("paused", true).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("paused", true).
+				Unstructured,
+		},
+		{
+			name:      "deployment in an included namespace is paused",
+			configMap: newPluginConfigMap("cm-1", pauseDeploymentsConfigName, map[string]string{"namespaces": "ns-1, ns-2"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-2").WithName("deploy-1").
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-2").WithName("deploy-1").
+				WithAnnotationValues(map[string]string{pausedByRestoreAnnotation: "restore-1"}).
+				WithSpecField("paused", true).
+				Unstructured,
+		},
+		{
+			name:      "deployment in a namespace that isn't included is unchanged",
+			configMap: newPluginConfigMap("cm-1", pauseDeploymentsConfigName, map[string]string{"namespaces": "ns-1"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-2").WithName("deploy-1").
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-2").WithName("deploy-1").
+				WithSpec().
+				Unstructured,
+		},
+		{
+			name:      "item that isn't a deployment is unchanged",
+			configMap: newPluginConfigMap("cm-1", pauseDeploymentsConfigName, nil),
+			obj: NewTestUnstructured().WithKind("StatefulSet").WithNamespace("ns-1").WithName("sts-1").
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("StatefulSet").WithNamespace("ns-1").WithName("sts-1").
+				WithSpec().
+				Unstructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewPauseDeploymentsAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, velerotest.NewTestRestore("velero", "restore-1", "").Restore)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// pluginConfigLabel is the label that identifies a config map as
+	// holding configuration for a plugin.
+	pluginConfigLabel = "velero.io/plugin-config"
+
+	// restoreItemActionKind is the value that a plugin config map's
+	// <plugin name> label must have for it to be used to configure a
+	// restore item action.
+	restoreItemActionKind = "RestoreItemAction"
+)
+
+// getPluginConfig returns the config map configuring the restore item action
+// with the specified name, or nil if there isn't one. A plugin config map is
+// labeled with velero.io/plugin-config and with <name>=RestoreItemAction, e.g.
+// velero.io/change-storage-class=RestoreItemAction.
+func getPluginConfig(name string, client corev1client.ConfigMapInterface) (*corev1api.ConfigMap, error) {
+	opts := metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s,%s=%s", pluginConfigLabel, name, restoreItemActionKind),
+	}
+
+	list, err := client.List(opts)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	if len(list.Items) == 0 {
+		return nil, nil
+	}
+
+	if len(list.Items) > 1 {
+		var items []string
+		for _, item := range list.Items {
+			items = append(items, item.Name)
+		}
+		return nil, errors.Errorf("found more than one ConfigMap matching label selector %q: %v", opts.LabelSelector, items)
+	}
+
+	return &list.Items[0], nil
+}
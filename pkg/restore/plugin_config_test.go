@@ -0,0 +1,128 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+func TestGetPluginConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMaps  []corev1api.ConfigMap
+		expected    *corev1api.ConfigMap
+		expectedErr bool
+	}{
+		{
+			name:     "no config maps returns nil",
+			expected: nil,
+		},
+		{
+			name: "config map for a different plugin is not returned",
+			configMaps: []corev1api.ConfigMap{
+				*newPluginConfigMap("cm-1", "velero.io/some-other-plugin", nil),
+			},
+			expected: nil,
+		},
+		{
+			name: "config map without the plugin-config label is not returned",
+			configMaps: []corev1api.ConfigMap{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "cm-1",
+						Labels: map[string]string{"velero.io/my-plugin": "RestoreItemAction"},
+					},
+				},
+			},
+			expected: nil,
+		},
+		{
+			name: "single matching config map is returned",
+			configMaps: []corev1api.ConfigMap{
+				*newPluginConfigMap("cm-1", "velero.io/my-plugin", map[string]string{"foo": "bar"}),
+				*newPluginConfigMap("cm-2", "velero.io/some-other-plugin", nil),
+			},
+			expected: newPluginConfigMap("cm-1", "velero.io/my-plugin", map[string]string{"foo": "bar"}),
+		},
+		{
+			name: "multiple matching config maps returns an error",
+			configMaps: []corev1api.ConfigMap{
+				*newPluginConfigMap("cm-1", "velero.io/my-plugin", nil),
+				*newPluginConfigMap("cm-2", "velero.io/my-plugin", nil),
+			},
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res, err := getPluginConfig("velero.io/my-plugin", &fakeConfigMapClient{configMaps: test.configMaps})
+
+			assert.Equal(t, test.expectedErr, err != nil)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+// newPluginConfigMap returns a config map with the labels needed for it to
+// configure the named restore item action.
+func newPluginConfigMap(name, plugin string, data map[string]string) *corev1api.ConfigMap {
+	return &corev1api.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "velero",
+			Name:      name,
+			Labels: map[string]string{
+				"velero.io/plugin-config": "",
+				plugin:                    "RestoreItemAction",
+			},
+		},
+		Data: data,
+	}
+}
+
+// fakeConfigMapClient is a ConfigMapInterface whose List returns the
+// config maps matching the label selector, and counts how often it's called.
+type fakeConfigMapClient struct {
+	corev1client.ConfigMapInterface
+
+	configMaps []corev1api.ConfigMap
+	listCalls  int
+}
+
+func (c *fakeConfigMapClient) List(opts metav1.ListOptions) (*corev1api.ConfigMapList, error) {
+	c.listCalls++
+
+	selector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	res := new(corev1api.ConfigMapList)
+	for _, cm := range c.configMaps {
+		if selector.Matches(labels.Set(cm.Labels)) {
+			res.Items = append(res.Items, cm)
+		}
+	}
+
+	return res, nil
+}
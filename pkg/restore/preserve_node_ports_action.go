@@ -0,0 +1,154 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+	"github.com/heptio/velero/pkg/util/collections"
+)
+
+const (
+	preserveNodePortsConfigName = "velero.io/preserve-node-ports"
+
+	// nodePortsKey is the key in the plugin's config map data that controls
+	// what's done with services' node ports.
+	nodePortsKey = "nodePorts"
+
+	// nodePortsPreserve keeps all of the node ports of NodePort and
+	// LoadBalancer services.
+	nodePortsPreserve = "preserve"
+	// nodePortsStrip removes all node ports, so they're reassigned.
+	nodePortsStrip = "strip"
+
+	// preserveNodePortsAnnotation marks a service whose node ports the
+	// service action should keep. The service action removes it.
+	preserveNodePortsAnnotation = "velero.io/preserve-node-ports"
+)
+
+type preserveNodePortsAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewPreserveNodePortsAction returns an ItemAction that, depending on the
+// plugin's config map, keeps all of a service's node ports or removes all of
+// them, including ones that were explicitly specified by the user.
+//
+// Restore item actions in the same plugin binary run in the order of their
+// names, so this action must be registered under a name that sorts before the
+// service action's, which otherwise removes node ports.
+func NewPreserveNodePortsAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &preserveNodePortsAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *preserveNodePortsAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"services"},
+	}, nil
+}
+
+func (a *preserveNodePortsAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing preserveNodePortsAction")
+	defer a.logger.Info("Done executing preserveNodePortsAction")
+
+	config, err := getPluginConfig(preserveNodePortsConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || config.Data[nodePortsKey] == "" {
+		a.logger.Debug("No node ports mode found")
+		return obj, nil, nil
+	}
+
+	mode := config.Data[nodePortsKey]
+	if mode != nodePortsPreserve && mode != nodePortsStrip {
+		return nil, nil, errors.Errorf("invalid value %q for %s in %s config, must be one of %q or %q", mode, nodePortsKey, preserveNodePortsConfigName, nodePortsPreserve, nodePortsStrip)
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	spec, err := collections.GetMap(item.UnstructuredContent(), "spec")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// node ports are only valid for these types, so services of any other
+	// type are left to the service action.
+	serviceType, _ := collections.GetString(spec, "type")
+	if serviceType != string(corev1api.ServiceTypeNodePort) && serviceType != string(corev1api.ServiceTypeLoadBalancer) {
+		log.Debugf("Service is of type %q, which doesn't have node ports", serviceType)
+		return obj, nil, nil
+	}
+
+	if mode == nodePortsStrip {
+		log.Info("Removing all of service's node ports")
+
+		if err := deleteAllNodePorts(spec); err != nil {
+			return nil, nil, err
+		}
+		return item, nil, nil
+	}
+
+	log.Info("Preserving service's node ports")
+
+	annotations := item.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[preserveNodePortsAnnotation] = "true"
+	item.SetAnnotations(annotations)
+
+	return item, nil, nil
+}
+
+// deleteAllNodePorts removes the node ports from all of a service's ports,
+// including any that were explicitly specified by the user.
+func deleteAllNodePorts(spec map[string]interface{}) error {
+	// ports are optional for headless services.
+	if _, ok := spec["ports"]; !ok {
+		return nil
+	}
+
+	ports, err := collections.GetSlice(spec, "ports")
+	if err != nil {
+		return err
+	}
+
+	for _, port := range ports {
+		delete(port.(map[string]interface{}), "nodePort")
+	}
+	return nil
+}
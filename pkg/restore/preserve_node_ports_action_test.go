@@ -0,0 +1,186 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestPreserveNodePortsActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+		},
+		{
+			name:      "NodePort service is marked to preserve its nodePorts in preserve mode",
+			configMap: newPluginConfigMap("cm-1", preserveNodePortsConfigName, map[string]string{"nodePorts": "preserve"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+					map[string]interface{}{"name": "admin", "nodePort": 9090},
+				}).Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithAnnotationValues(map[string]string{preserveNodePortsAnnotation: "true"}).
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+					map[string]interface{}{"name": "admin", "nodePort": 9090},
+				}).Unstructured,
+		},
+		{
+			name:      "LoadBalancer service is marked to preserve its nodePorts in preserve mode",
+			configMap: newPluginConfigMap("cm-1", preserveNodePortsConfigName, map[string]string{"nodePorts": "preserve"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "LoadBalancer").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithAnnotationValues(map[string]string{preserveNodePortsAnnotation: "true"}).
+				WithSpecField("type", "LoadBalancer").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+		},
+		{
+			name:      "ClusterIP service is unchanged in preserve mode",
+			configMap: newPluginConfigMap("cm-1", preserveNodePortsConfigName, map[string]string{"nodePorts": "preserve"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+		},
+		{
+			name:      "all nodePorts of a multi-port service are deleted in strip mode",
+			configMap: newPluginConfigMap("cm-1", preserveNodePortsConfigName, map[string]string{"nodePorts": "strip"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithAnnotationValues(map[string]string{
+					annotationLastAppliedConfig: svcJSON(corev1api.ServicePort{Name: "http", NodePort: 8080}),
+				}).
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+					map[string]interface{}{"name": "admin", "nodePort": 9090},
+				}).Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithAnnotationValues(map[string]string{
+					annotationLastAppliedConfig: svcJSON(corev1api.ServicePort{Name: "http", NodePort: 8080}),
+				}).
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http"},
+					map[string]interface{}{"name": "admin"},
+				}).Unstructured,
+		},
+		{
+			name:      "ClusterIP service is unchanged in strip mode",
+			configMap: newPluginConfigMap("cm-1", preserveNodePortsConfigName, map[string]string{"nodePorts": "strip"}),
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithSpecField("type", "ClusterIP").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+				}).Unstructured,
+		},
+		{
+			name:        "invalid nodePorts mode should error",
+			configMap:   newPluginConfigMap("cm-1", preserveNodePortsConfigName, map[string]string{"nodePorts": "foo"}),
+			obj:         NewTestUnstructured().WithName("svc-1").WithSpecField("ports", []interface{}{}).Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewPreserveNodePortsAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+// TestPreserveNodePortsWithServiceAction checks that node ports preserved by
+// the preserve node ports action are kept by the service action, which runs
+// after it.
+func TestPreserveNodePortsWithServiceAction(t *testing.T) {
+	configMapClient := &fakeConfigMapClient{
+		configMaps: []corev1api.ConfigMap{
+			*newPluginConfigMap("cm-1", preserveNodePortsConfigName, map[string]string{"nodePorts": "preserve"}),
+		},
+	}
+
+	obj := NewTestUnstructured().WithName("svc-1").
+		WithSpecField("type", "NodePort").
+		WithSpecField("ports", []interface{}{
+			map[string]interface{}{"name": "http", "nodePort": 8080},
+		}).Unstructured
+
+	res, _, err := NewPreserveNodePortsAction(velerotest.NewLogger(), configMapClient).Execute(obj, nil)
+	assert.NoError(t, err)
+
+	res, _, err = NewServiceAction(velerotest.NewLogger()).Execute(res, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, NewTestUnstructured().WithName("svc-1").
+		WithAnnotationValues(map[string]string{}).
+		WithSpecField("type", "NodePort").
+		WithSpecField("ports", []interface{}{
+			map[string]interface{}{"name": "http", "nodePort": 8080},
+		}).Unstructured, res)
+}
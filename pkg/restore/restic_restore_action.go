@@ -70,7 +70,20 @@ func (a *resticRestoreAction) Execute(obj runtime.Unstructured, restore *api.Res
 
 	log := a.logger.WithField("pod", kube.NamespaceAndName(&pod))
 
-	volumeSnapshots := restic.GetPodSnapshotAnnotations(&pod)
+	// the pod's namespace isn't mapped until after the restore item actions
+	// run, so map it here to match the restore's annotations against the
+	// namespace the pod is restored into, as the restorer does.
+	namespace := pod.Namespace
+	if target, ok := restore.Spec.NamespaceMapping[namespace]; ok {
+		namespace = target
+	}
+
+	// the init container only waits for the volumes that will be
+	// restored, so volumes that the restore excludes aren't waited for.
+	volumeSnapshots, skippedVolumes := restic.GetVolumesToRestore(restore, &pod, namespace)
+	if len(skippedVolumes) > 0 {
+		log.Debugf("Restore excludes restic snapshots of volumes %v", skippedVolumes)
+	}
 	if len(volumeSnapshots) == 0 {
 		log.Debug("No restic snapshot ID annotations found")
 		return obj, nil, nil
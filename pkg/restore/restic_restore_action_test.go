@@ -0,0 +1,98 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/heptio/velero/pkg/restic"
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestResticRestoreActionExecute(t *testing.T) {
+	tests := []struct {
+		name               string
+		namespaceMapping   map[string]string
+		restoreAnnotations map[string]string
+		expectedMounts     []string
+	}{
+		{
+			name:           "all volumes with snapshots are waited for",
+			expectedMounts: []string{"vol-1", "vol-2"},
+		},
+		{
+			name:               "volumes skipped by the restore aren't waited for",
+			restoreAnnotations: map[string]string{restic.SkipRestoreVolumesAnnotation: "ns-1/pod-1/vol-2"},
+			expectedMounts:     []string{"vol-1"},
+		},
+		{
+			name:               "skipped volumes are matched against the namespace the pod is restored into",
+			namespaceMapping:   map[string]string{"ns-1": "ns-2"},
+			restoreAnnotations: map[string]string{restic.SkipRestoreVolumesAnnotation: "ns-2/pod-1/vol-2"},
+			expectedMounts:     []string{"vol-1"},
+		},
+		{
+			name:               "included volumes are matched against the namespace the pod is restored into",
+			namespaceMapping:   map[string]string{"ns-1": "ns-2"},
+			restoreAnnotations: map[string]string{restic.RestoreVolumesAnnotation: "ns-1/pod-1/vol-1,ns-2/pod-1/vol-2"},
+			expectedMounts:     []string{"vol-2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1api.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns-1",
+					Name:      "pod-1",
+				},
+			}
+			restic.SetPodSnapshotAnnotation(pod, "vol-1", "snap-1")
+			restic.SetPodSnapshotAnnotation(pod, "vol-2", "snap-2")
+
+			obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(pod)
+			require.NoError(t, err)
+
+			restore := velerotest.NewTestRestore("velero", "restore-1", "").Restore
+			restore.Annotations = test.restoreAnnotations
+			restore.Spec.NamespaceMapping = test.namespaceMapping
+
+			action := NewResticRestoreAction(velerotest.NewLogger())
+
+			res, warning, err := action.Execute(&unstructured.Unstructured{Object: obj}, restore)
+			require.NoError(t, err)
+			require.NoError(t, warning)
+
+			var restored corev1api.Pod
+			require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(res.UnstructuredContent(), &restored))
+			require.Len(t, restored.Spec.InitContainers, 1)
+
+			var mounts []string
+			for _, mount := range restored.Spec.InitContainers[0].VolumeMounts {
+				mounts = append(mounts, mount.Name)
+			}
+			assert.ElementsMatch(t, test.expectedMounts, mounts)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	scaleDownConfigName = "velero.io/scale-down"
+
+	// keys in the scale-down plugin's config map data. All are optional.
+	scaleDownReplicasKey      = "replicas"
+	scaleDownNamespacesKey    = "namespaces"
+	scaleDownLabelSelectorKey = "labelSelector"
+
+	// originalReplicasAnnotation records a workload's replica count from the
+	// backup when the scale-down action changes it.
+	originalReplicasAnnotation = "velero.io/original-replicas"
+)
+
+type scaleDownAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewScaleDownAction returns an ItemAction that sets the replica count of
+// deployments, statefulsets and replicasets to zero, or to the value in the
+// plugin's config map, if the plugin's config map exists.
+func NewScaleDownAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &scaleDownAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *scaleDownAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"deployments.apps", "statefulsets.apps", "replicasets.apps"},
+	}, nil
+}
+
+func (a *scaleDownAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing scaleDownAction")
+	defer a.logger.Info("Done executing scaleDownAction")
+
+	config, err := getPluginConfig(scaleDownConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// unlike the other config map-based actions, this one is enabled by the
+	// presence of the config map, since all of its settings are optional.
+	if config == nil {
+		a.logger.Debug("No scale-down config found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	replicas := int64(0)
+	if val := config.Data[scaleDownReplicasKey]; val != "" {
+		if replicas, err = strconv.ParseInt(val, 10, 32); err != nil || replicas < 0 {
+			return nil, nil, errors.Errorf("invalid value %q for %s in scale-down config", val, scaleDownReplicasKey)
+		}
+	}
+
+	if val := config.Data[scaleDownNamespacesKey]; val != "" && !containsNamespace(val, item.GetNamespace()) {
+		log.Debug("Item's namespace is not included in scale-down config")
+		return obj, nil, nil
+	}
+
+	if val := config.Data[scaleDownLabelSelectorKey]; val != "" {
+		selector, err := labels.Parse(val)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "invalid value %q for %s in scale-down config", val, scaleDownLabelSelectorKey)
+		}
+		if !selector.Matches(labels.Set(item.GetLabels())) {
+			log.Debug("Item's labels don't match scale-down config's label selector")
+			return obj, nil, nil
+		}
+	}
+
+	// replicas defaults to 1 when it's not specified.
+	originalReplicas, found, err := unstructured.NestedInt64(item.UnstructuredContent(), "spec", "replicas")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error getting item's spec.replicas")
+	}
+	if !found {
+		originalReplicas = 1
+	}
+
+	if originalReplicas == replicas {
+		return obj, nil, nil
+	}
+
+	log.Infof("Updating item's replicas from %d to %d", originalReplicas, replicas)
+
+	if err := unstructured.SetNestedField(item.UnstructuredContent(), replicas, "spec", "replicas"); err != nil {
+		return nil, nil, errors.Wrap(err, "unable to set item's spec.replicas")
+	}
+
+	annotations := item.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[originalReplicasAnnotation] = strconv.FormatInt(originalReplicas, 10)
+	item.SetAnnotations(annotations)
+
+	return item, nil, nil
+}
+
+// containsNamespace returns true if namespaces, a comma-separated list,
+// includes namespace.
+func containsNamespace(namespaces, namespace string) bool {
+	for _, ns := range strings.Split(namespaces, ",") {
+		if strings.TrimSpace(ns) == namespace {
+			return true
+		}
+	}
+	return false
+}
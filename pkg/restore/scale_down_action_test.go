@@ -0,0 +1,158 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestScaleDownActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+		},
+		{
+			name:      "deployment is scaled to zero",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, nil),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithAnnotationValues(map[string]string{originalReplicasAnnotation: "3"}).
+				WithSpecField("replicas", int64(0)).
+				Unstructured,
+		},
+		{
+			name:      "statefulset is scaled to zero",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, nil),
+			obj: NewTestUnstructured().WithKind("StatefulSet").WithNamespace("ns-1").WithName("sts-1").
+				WithSpecField("replicas", int64(2)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("StatefulSet").WithNamespace("ns-1").WithName("sts-1").
+				WithAnnotationValues(map[string]string{originalReplicasAnnotation: "2"}).
+				WithSpecField("replicas", int64(0)).
+				Unstructured,
+		},
+		{
+			name:      "replicaset with no replicas specified is scaled to configured value",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, map[string]string{"replicas": "0"}),
+			obj: NewTestUnstructured().WithKind("ReplicaSet").WithNamespace("ns-1").WithName("rs-1").
+				WithSpec().
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("ReplicaSet").WithNamespace("ns-1").WithName("rs-1").
+				WithAnnotationValues(map[string]string{originalReplicasAnnotation: "1"}).
+				WithSpecField("replicas", int64(0)).
+				Unstructured,
+		},
+		{
+			name:      "existing annotations are kept",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, map[string]string{"replicas": "1"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithAnnotationValues(map[string]string{"foo": "bar"}).
+				WithSpecField("replicas", int64(5)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithAnnotationValues(map[string]string{"foo": "bar", originalReplicasAnnotation: "5"}).
+				WithSpecField("replicas", int64(1)).
+				Unstructured,
+		},
+		{
+			name:      "item already at configured replicas is unchanged",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, nil),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(0)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(0)).
+				Unstructured,
+		},
+		{
+			name:      "item in a namespace that isn't included is unchanged",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, map[string]string{"namespaces": "ns-2, ns-3"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+		},
+		{
+			name:      "item in an included namespace is scaled down",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, map[string]string{"namespaces": "ns-2, ns-1"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithAnnotationValues(map[string]string{originalReplicasAnnotation: "3"}).
+				WithSpecField("replicas", int64(0)).
+				Unstructured,
+		},
+		{
+			name:      "item not matching the label selector is unchanged",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, map[string]string{"labelSelector": "app=foo"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+		},
+		{
+			name:      "invalid replicas value returns an error",
+			configMap: newPluginConfigMap("cm-1", scaleDownConfigName, map[string]string{"replicas": "-1"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithNamespace("ns-1").WithName("deploy-1").
+				WithSpecField("replicas", int64(3)).
+				Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewScaleDownAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	scaleResourcesConfigName = "velero.io/scale-resources"
+
+	// scaleResourcesModeKey and scaleResourcesFactorKey are keys in the
+	// scale-resources plugin's config map data.
+	scaleResourcesModeKey   = "mode"
+	scaleResourcesFactorKey = "factor"
+
+	// scaleResourcesModeClear removes containers' resource requests and
+	// limits.
+	scaleResourcesModeClear = "clear"
+
+	// scaleResourcesModeScale multiplies containers' resource requests and
+	// limits by the configured factor.
+	scaleResourcesModeScale = "scale"
+)
+
+type scaleResourcesAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+}
+
+// NewScaleResourcesAction returns an ItemAction that clears or scales the
+// resource requests and limits of a pod's containers, or those of a
+// workload's pod template, as configured in the plugin's config map.
+func NewScaleResourcesAction(logger logrus.FieldLogger, configMapClient corev1client.ConfigMapInterface) ItemAction {
+	return &scaleResourcesAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+	}
+}
+
+func (a *scaleResourcesAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"pods", "deployments.apps", "statefulsets.apps", "daemonsets.apps"},
+	}, nil
+}
+
+func (a *scaleResourcesAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing scaleResourcesAction")
+	defer a.logger.Info("Done executing scaleResourcesAction")
+
+	config, err := getPluginConfig(scaleResourcesConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil {
+		a.logger.Debug("No scale-resources config found")
+		return obj, nil, nil
+	}
+
+	var factor float64
+	switch mode := config.Data[scaleResourcesModeKey]; mode {
+	case scaleResourcesModeClear:
+	case scaleResourcesModeScale:
+		factor, err = strconv.ParseFloat(config.Data[scaleResourcesFactorKey], 64)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "error parsing scale-resources %s %q", scaleResourcesFactorKey, config.Data[scaleResourcesFactorKey])
+		}
+		if factor <= 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+			return nil, nil, errors.Errorf("scale-resources %s must be a positive number, got %q", scaleResourcesFactorKey, config.Data[scaleResourcesFactorKey])
+		}
+	default:
+		return nil, nil, errors.Errorf("scale-resources %s must be %s or %s, got %q", scaleResourcesModeKey, scaleResourcesModeClear, scaleResourcesModeScale, mode)
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	podSpec, err := getPodSpec(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	if podSpec == nil {
+		log.Debug("Item has no pod spec")
+		return obj, nil, nil
+	}
+
+	for _, containers := range []interface{}{podSpec["initContainers"], podSpec["containers"]} {
+		for _, container := range mapsIn(containers) {
+			resources, ok := container["resources"].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			// requests and limits are always cleared or scaled together, so
+			// that a container's QoS class doesn't change: clearing only
+			// requests would default them to the limits, and scaling them
+			// by the same factor keeps equal requests and limits equal.
+			if factor == 0 {
+				log.Infof("Clearing resource requests and limits of container %v", container["name"])
+				delete(resources, "requests")
+				delete(resources, "limits")
+				if len(resources) == 0 {
+					delete(container, "resources")
+				}
+				continue
+			}
+
+			log.Infof("Scaling resource requests and limits of container %v by %v", container["name"], factor)
+			for _, field := range []string{"requests", "limits"} {
+				quantities, ok := resources[field].(map[string]interface{})
+				if !ok {
+					continue
+				}
+
+				for name, val := range quantities {
+					scaled, err := scaleQuantity(name, val, factor)
+					if err != nil {
+						return nil, nil, errors.Wrapf(err, "error scaling %s.%s of container %v", field, name, container["name"])
+					}
+					quantities[name] = scaled
+				}
+			}
+		}
+	}
+
+	return item, nil, nil
+}
+
+// scaleQuantity returns the resource quantity val, multiplied by factor and
+// rounded up. CPU is rounded up to a whole millicore, and other resources,
+// e.g. memory and GPUs, to a whole unit, since they can't be fractional.
+func scaleQuantity(name string, val interface{}, factor float64) (string, error) {
+	// quantities can be numbers as well as strings in the item's JSON.
+	q, err := resource.ParseQuantity(fmt.Sprintf("%v", val))
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+
+	var scaled *resource.Quantity
+	if name == string(corev1api.ResourceCPU) {
+		scaled = resource.NewMilliQuantity(int64(math.Ceil(float64(q.MilliValue())*factor)), q.Format)
+	} else {
+		scaled = resource.NewQuantity(int64(math.Ceil(float64(q.Value())*factor)), q.Format)
+	}
+
+	return scaled.String(), nil
+}
@@ -0,0 +1,151 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestScaleResourcesActionExecute(t *testing.T) {
+	containers := func(requests, limits map[string]interface{}) interface{} {
+		resources := map[string]interface{}{}
+		if requests != nil {
+			resources["requests"] = requests
+		}
+		if limits != nil {
+			resources["limits"] = limits
+		}
+
+		container := map[string]interface{}{"name": "container-1"}
+		if len(resources) > 0 {
+			container["resources"] = resources
+		}
+		return []interface{}{container}
+	}
+
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(map[string]interface{}{"cpu": "2"}, nil)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(map[string]interface{}{"cpu": "2"}, nil)).
+				Unstructured,
+		},
+		{
+			name:      "clear mode removes requests and limits",
+			configMap: newPluginConfigMap("cm", scaleResourcesConfigName, map[string]string{"mode": "clear"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(
+					map[string]interface{}{"cpu": "2", "memory": "4Gi"},
+					map[string]interface{}{"cpu": "4", "memory": "8Gi"},
+				)).
+				WithSpecField("initContainers", containers(map[string]interface{}{"cpu": "500m"}, nil)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(nil, nil)).
+				WithSpecField("initContainers", containers(nil, nil)).
+				Unstructured,
+		},
+		{
+			name:      "scale mode halves the requests and limits of a deployment's pod template",
+			configMap: newPluginConfigMap("cm", scaleResourcesConfigName, map[string]string{"mode": "scale", "factor": "0.5"}),
+			obj: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": containers(
+							map[string]interface{}{"cpu": "1500m", "memory": "4Gi"},
+							map[string]interface{}{"cpu": int64(3), "memory": "8Gi", "nvidia.com/gpu": "1"},
+						),
+					},
+				}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Deployment").WithName("deploy-1").
+				WithSpecField("template", map[string]interface{}{
+					"spec": map[string]interface{}{
+						"containers": containers(
+							map[string]interface{}{"cpu": "750m", "memory": "2Gi"},
+							map[string]interface{}{"cpu": "1500m", "memory": "4Gi", "nvidia.com/gpu": "1"},
+						),
+					},
+				}).
+				Unstructured,
+		},
+		{
+			name:      "scale mode with equal requests and limits keeps them equal",
+			configMap: newPluginConfigMap("cm", scaleResourcesConfigName, map[string]string{"mode": "scale", "factor": "0.5"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(
+					map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+					map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+				)).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(
+					map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+					map[string]interface{}{"cpu": "500m", "memory": "512Mi"},
+				)).
+				Unstructured,
+		},
+		{
+			name:      "scale mode with a factor that isn't positive returns an error",
+			configMap: newPluginConfigMap("cm", scaleResourcesConfigName, map[string]string{"mode": "scale", "factor": "-0.5"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(map[string]interface{}{"cpu": "1"}, nil)).
+				Unstructured,
+			expectedErr: true,
+		},
+		{
+			name:      "unknown mode returns an error",
+			configMap: newPluginConfigMap("cm", scaleResourcesConfigName, map[string]string{"mode": "shrink"}),
+			obj: NewTestUnstructured().WithKind("Pod").WithName("pod-1").
+				WithSpecField("containers", containers(map[string]interface{}{"cpu": "1"}, nil)).
+				Unstructured,
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewScaleResourcesAction(velerotest.NewLogger(), configMapClient)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
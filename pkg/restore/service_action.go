@@ -56,9 +56,22 @@ func (a *serviceAction) Execute(obj runtime.Unstructured, restore *api.Restore)
 		delete(spec, "clusterIP")
 	}
 
+	// the preserve node ports action, which runs before this one, marks the
+	// services whose node ports it's configured to keep.
+	metadata, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if annotations := metadata.GetAnnotations(); annotations[preserveNodePortsAnnotation] != "" {
+		delete(annotations, preserveNodePortsAnnotation)
+		metadata.SetAnnotations(annotations)
+		return obj, nil, nil
+	}
+
 	if err := deleteNodePorts(obj, &spec); err != nil {
 		return nil, nil, err
 	}
+
 	return obj, nil, nil
 }
 
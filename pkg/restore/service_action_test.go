@@ -177,6 +177,23 @@ func TestServiceActionExecute(t *testing.T) {
 					},
 				}).Unstructured,
 		},
+		{
+			name: "all nodePorts should be preserved when the service is marked to preserve them",
+			obj: NewTestUnstructured().WithName("svc-1").
+				WithAnnotationValues(map[string]string{preserveNodePortsAnnotation: "true"}).
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+					map[string]interface{}{"name": "admin", "nodePort": 9090},
+				}).Unstructured,
+			expectedRes: NewTestUnstructured().WithName("svc-1").
+				WithAnnotationValues(map[string]string{}).
+				WithSpecField("type", "NodePort").
+				WithSpecField("ports", []interface{}{
+					map[string]interface{}{"name": "http", "nodePort": 8080},
+					map[string]interface{}{"name": "admin", "nodePort": 9090},
+				}).Unstructured,
+		},
 	}
 
 	for _, test := range tests {
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	api "github.com/heptio/velero/pkg/apis/velero/v1"
+)
+
+const (
+	setSelectedNodeConfigName = "velero.io/set-selected-node"
+
+	// clearMissingNodeKey is a reserved key in the plugin's config map data.
+	// It can't be mistaken for a node mapping, since node names can't
+	// contain uppercase letters.
+	clearMissingNodeKey = "clearMissingNode"
+
+	// selectedNodeAnnotation is set on a PVC by the scheduler when a pod
+	// that uses it is scheduled, for storage classes with
+	// WaitForFirstConsumer volume binding, so the volume is provisioned on
+	// or near that node.
+	selectedNodeAnnotation = "volume.kubernetes.io/selected-node"
+)
+
+type setSelectedNodeAction struct {
+	logger          logrus.FieldLogger
+	configMapClient corev1client.ConfigMapInterface
+	nodeClient      corev1client.NodeInterface
+}
+
+// NewSetSelectedNodeAction returns an ItemAction that updates a PVC's
+// selected-node annotation if a mapping for its node is found in the
+// plugin's config map.
+func NewSetSelectedNodeAction(
+	logger logrus.FieldLogger,
+	configMapClient corev1client.ConfigMapInterface,
+	nodeClient corev1client.NodeInterface,
+) ItemAction {
+	return &setSelectedNodeAction{
+		logger:          logger,
+		configMapClient: configMapClient,
+		nodeClient:      nodeClient,
+	}
+}
+
+func (a *setSelectedNodeAction) AppliesTo() (ResourceSelector, error) {
+	return ResourceSelector{
+		IncludedResources: []string{"persistentvolumeclaims"},
+	}, nil
+}
+
+func (a *setSelectedNodeAction) Execute(obj runtime.Unstructured, restore *api.Restore) (runtime.Unstructured, error, error) {
+	a.logger.Info("Executing setSelectedNodeAction")
+	defer a.logger.Info("Done executing setSelectedNodeAction")
+
+	config, err := getPluginConfig(setSelectedNodeConfigName, a.configMapClient)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil || len(config.Data) == 0 {
+		a.logger.Debug("No node mappings found")
+		return obj, nil, nil
+	}
+
+	item, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, nil, errors.Errorf("object was of unexpected type %T", obj)
+	}
+
+	log := a.logger.WithFields(logrus.Fields{
+		"kind":      item.GetKind(),
+		"namespace": item.GetNamespace(),
+		"name":      item.GetName(),
+	})
+
+	annotations := item.GetAnnotations()
+	node := annotations[selectedNodeAnnotation]
+	if node == "" {
+		log.Debug("Item has no selected node")
+		return obj, nil, nil
+	}
+
+	newNode, ok := config.Data[node]
+	if !ok || node == clearMissingNodeKey {
+		log.Debugf("No mapping found for node %s", node)
+		return obj, nil, nil
+	}
+
+	if newNode != "" {
+		_, err := a.nodeClient.Get(newNode, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err) && config.Data[clearMissingNodeKey] == "true":
+			log.Warnf("Node %s doesn't exist, so the item's selected node will be removed because %s is set", newNode, clearMissingNodeKey)
+			newNode = ""
+		case err != nil:
+			return nil, nil, errors.Wrapf(err, "error getting node %s from API", newNode)
+		}
+	}
+
+	// without the annotation, the scheduler selects a node for the PVC when
+	// a pod that uses it is scheduled.
+	if newNode == "" {
+		log.Infof("Removing item's selected node %s", node)
+		delete(annotations, selectedNodeAnnotation)
+	} else {
+		log.Infof("Updating item's selected node from %s to %s", node, newNode)
+		annotations[selectedNodeAnnotation] = newNode
+	}
+	item.SetAnnotations(annotations)
+
+	return item, nil, nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2019 the Heptio Ark contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restore
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1api "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+
+	velerotest "github.com/heptio/velero/pkg/util/test"
+)
+
+func TestSetSelectedNodeActionExecute(t *testing.T) {
+	tests := []struct {
+		name        string
+		configMap   *corev1api.ConfigMap
+		nodes       []string
+		obj         runtime.Unstructured
+		expectedErr bool
+		expectedRes runtime.Unstructured
+	}{
+		{
+			name: "no config map leaves the item unchanged",
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1"}).
+				Unstructured,
+		},
+		{
+			name:      "item with no selected node is unchanged",
+			configMap: newPluginConfigMap("cm-1", setSelectedNodeConfigName, map[string]string{"node-1": "node-2"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{"foo": "bar"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{"foo": "bar"}).
+				Unstructured,
+		},
+		{
+			name:      "item with no mapping for its selected node is unchanged",
+			configMap: newPluginConfigMap("cm-1", setSelectedNodeConfigName, map[string]string{"node-2": "node-3"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1"}).
+				Unstructured,
+		},
+		{
+			name:      "item with a mapping to an existing node has its selected node updated",
+			configMap: newPluginConfigMap("cm-1", setSelectedNodeConfigName, map[string]string{"node-1": "node-2"}),
+			nodes:     []string{"node-2"},
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-2"}).
+				Unstructured,
+		},
+		{
+			name:      "mapping to an empty name removes the selected node",
+			configMap: newPluginConfigMap("cm-1", setSelectedNodeConfigName, map[string]string{"node-1": ""}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1", "foo": "bar"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{"foo": "bar"}).
+				Unstructured,
+		},
+		{
+			name:      "mapping to a node that doesn't exist returns an error",
+			configMap: newPluginConfigMap("cm-1", setSelectedNodeConfigName, map[string]string{"node-1": "node-2"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1"}).
+				Unstructured,
+			expectedErr: true,
+		},
+		{
+			name:      "mapping to a node that doesn't exist removes the selected node when configured to",
+			configMap: newPluginConfigMap("cm-1", setSelectedNodeConfigName, map[string]string{"node-1": "node-2", clearMissingNodeKey: "true"}),
+			obj: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{selectedNodeAnnotation: "node-1", "foo": "bar"}).
+				Unstructured,
+			expectedRes: NewTestUnstructured().WithName("pvc-1").
+				WithAnnotationValues(map[string]string{"foo": "bar"}).
+				Unstructured,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			configMapClient := new(fakeConfigMapClient)
+			if test.configMap != nil {
+				configMapClient.configMaps = append(configMapClient.configMaps, *test.configMap)
+			}
+
+			action := NewSetSelectedNodeAction(
+				velerotest.NewLogger(),
+				configMapClient,
+				&fakeNodeClient{names: test.nodes},
+			)
+
+			res, _, err := action.Execute(test.obj, nil)
+
+			if assert.Equal(t, test.expectedErr, err != nil) && !test.expectedErr {
+				assert.Equal(t, test.expectedRes, res)
+			}
+		})
+	}
+}
+
+// fakeNodeClient is a NodeInterface whose Get returns a node if its name
+// is in names, or a not-found error otherwise.
+type fakeNodeClient struct {
+	corev1client.NodeInterface
+
+	names []string
+}
+
+func (c *fakeNodeClient) Get(name string, opts metav1.GetOptions) (*corev1api.Node, error) {
+	for _, n := range c.names {
+		if n == name {
+			return &corev1api.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+		}
+	}
+
+	return nil, apierrors.NewNotFound(corev1api.Resource("nodes"), name)
+}
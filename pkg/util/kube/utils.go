@@ -49,8 +49,27 @@ func EnsureNamespaceExists(namespace *corev1api.Namespace, client corev1client.N
 	}
 }
 
+// pvcNotBoundError is the error GetVolumeDirectory returns for a volume
+// whose PVC isn't bound yet.
+type pvcNotBoundError struct {
+	namespace string
+	name      string
+}
+
+func (e *pvcNotBoundError) Error() string {
+	return fmt.Sprintf("PVC %s/%s is not bound", e.namespace, e.name)
+}
+
+// IsPVCNotBound returns true if err is GetVolumeDirectory's error for a volume
+// whose PVC isn't bound yet, so callers can wait for it to be bound and try again.
+func IsPVCNotBound(err error) bool {
+	_, ok := errors.Cause(err).(*pvcNotBoundError)
+	return ok
+}
+
 // GetVolumeDirectory gets the name of the directory on the host, under /var/lib/kubelet/pods/<podUID>/volumes/,
-// where the specified volume lives.
+// where the specified volume lives. For a PVC volume, the directory is named after the PVC's bound PV, so
+// GetVolumeDirectory returns an error for which IsPVCNotBound is true if the PVC isn't bound yet.
 func GetVolumeDirectory(pod *corev1api.Pod, volumeName string, pvcLister corev1listers.PersistentVolumeClaimLister) (string, error) {
 	var volume *corev1api.Volume
 
@@ -74,5 +93,9 @@ func GetVolumeDirectory(pod *corev1api.Pod, volumeName string, pvcLister corev1l
 		return "", errors.WithStack(err)
 	}
 
+	if pvc.Status.Phase != corev1api.ClaimBound || pvc.Spec.VolumeName == "" {
+		return "", errors.WithStack(&pvcNotBoundError{namespace: pvc.Namespace, name: pvc.Name})
+	}
+
 	return pvc.Spec.VolumeName, nil
 }
@@ -18,6 +18,13 @@ package kube
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1api "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 func TestNamespaceAndName(t *testing.T) {
@@ -27,3 +34,75 @@ func TestNamespaceAndName(t *testing.T) {
 func TestEnsureNamespaceExists(t *testing.T) {
 	//TODO
 }
+
+func TestGetVolumeDirectory(t *testing.T) {
+	pod := &corev1api.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "pod-1"},
+		Spec: corev1api.PodSpec{
+			Volumes: []corev1api.Volume{
+				{Name: "empty-dir"},
+				{
+					Name: "pvc",
+					VolumeSource: corev1api.VolumeSource{
+						PersistentVolumeClaim: &corev1api.PersistentVolumeClaimVolumeSource{ClaimName: "pvc-1"},
+					},
+				},
+			},
+		},
+	}
+
+	unboundPVC := &corev1api.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "ns-1", Name: "pvc-1"},
+		Status:     corev1api.PersistentVolumeClaimStatus{Phase: corev1api.ClaimPending},
+	}
+	boundPVC := unboundPVC.DeepCopy()
+	boundPVC.Spec.VolumeName = "pv-1"
+	boundPVC.Status.Phase = corev1api.ClaimBound
+
+	newLister := func(pvc *corev1api.PersistentVolumeClaim) corev1listers.PersistentVolumeClaimLister {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		if pvc != nil {
+			require.NoError(t, indexer.Add(pvc))
+		}
+		return corev1listers.NewPersistentVolumeClaimLister(indexer)
+	}
+
+	t.Run("non-PVC volume returns the volume's name", func(t *testing.T) {
+		lister := newLister(nil)
+
+		dir, err := GetVolumeDirectory(pod, "empty-dir", lister)
+		require.NoError(t, err)
+		assert.Equal(t, "empty-dir", dir)
+	})
+
+	t.Run("volume not in pod returns an error", func(t *testing.T) {
+		lister := newLister(nil)
+
+		_, err := GetVolumeDirectory(pod, "missing", lister)
+		assert.EqualError(t, err, "volume not found in pod")
+	})
+
+	t.Run("bound PVC returns its volume's name", func(t *testing.T) {
+		lister := newLister(boundPVC)
+
+		dir, err := GetVolumeDirectory(pod, "pvc", lister)
+		require.NoError(t, err)
+		assert.Equal(t, "pv-1", dir)
+	})
+
+	t.Run("unbound PVC returns a not-bound error", func(t *testing.T) {
+		lister := newLister(unboundPVC)
+
+		_, err := GetVolumeDirectory(pod, "pvc", lister)
+		assert.EqualError(t, err, "PVC ns-1/pvc-1 is not bound")
+		assert.True(t, IsPVCNotBound(err))
+	})
+
+	t.Run("missing PVC returns an error", func(t *testing.T) {
+		lister := newLister(nil)
+
+		_, err := GetVolumeDirectory(pod, "pvc", lister)
+		require.Error(t, err)
+		assert.False(t, IsPVCNotBound(err))
+	})
+}